@@ -4,10 +4,12 @@ import (
 	"net"
 	"testing"
 	"time"
+
+	"github.com/skerkour/rz/internal/json"
 )
 
 func TestArray(t *testing.T) {
-	ev := &Event{timeFieldFormat: DefaultTimeFieldFormat}
+	ev := &Event{timeFieldFormat: DefaultTimeFieldFormat, encoder: json.Encoder{}}
 	a := ev.arr().
 		Bool(true).
 		Int(1).
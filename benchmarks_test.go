@@ -5,6 +5,8 @@ import (
 	"io/ioutil"
 	"testing"
 	"time"
+
+	"github.com/skerkour/rz/logfmt"
 )
 
 var (
@@ -42,6 +44,30 @@ func BenchmarkInfo(b *testing.B) {
 	})
 }
 
+func BenchmarkInfoDiodeWriter(b *testing.B) {
+	w := DiodeWriter(ioutil.Discard, 10000, DiodePollInterval(10*time.Millisecond))
+	defer w.Close()
+	logger := New(Writer(w))
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Info(fakeMessage)
+		}
+	})
+}
+
+func BenchmarkInfoAsyncWriter(b *testing.B) {
+	w := AsyncWriter(ioutil.Discard, AsyncQueueSize(10000), AsyncDropPolicy(AsyncDropNewest))
+	defer w.Close()
+	logger := New(Writer(w))
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Info(fakeMessage)
+		}
+	})
+}
+
 func BenchmarkContextFields(b *testing.B) {
 	logger := New(
 		Writer(ioutil.Discard),
@@ -71,6 +97,39 @@ func BenchmarkContextAppend(b *testing.B) {
 
 func BenchmarkLogFields(b *testing.B) {
 	logger := New(Writer(ioutil.Discard))
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Info(fakeMessage,
+				String("string", "four!"),
+				Time("time", time.Time{}),
+				Int("int", 123),
+				Float32("float", -2.203230293249593),
+			)
+		}
+	})
+}
+
+func BenchmarkLogFieldsHook(b *testing.B) {
+	hook := HookFunc(func(e *Event, level LogLevel, message string) {})
+	logger := New(Writer(ioutil.Discard), Fields(String("service", "api")), Hooks(hook))
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Info(fakeMessage,
+				String("string", "four!"),
+				Time("time", time.Time{}),
+				Int("int", 123),
+				Float32("float", -2.203230293249593),
+			)
+		}
+	})
+}
+
+func BenchmarkLogFieldsLogfmt(b *testing.B) {
+	logger := New(Writer(ioutil.Discard), WithEncoder(logfmt.Encoder{}))
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
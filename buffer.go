@@ -0,0 +1,197 @@
+package rz
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBufferMaxEvents is the default maximum number of events held by a LogBuffer.
+const DefaultBufferMaxEvents = 1000
+
+// BufferOption is used to configure a LogBuffer.
+type BufferOption func(b *LogBuffer)
+
+// BufferThreshold update the level below which events are held. It defaults to the
+// level of the buffered logger, read as events are written so the changes of its
+// AtomicLevel or LevelFilter apply.
+func BufferThreshold(level LogLevel) BufferOption {
+	return func(b *LogBuffer) {
+		b.threshold = level
+		b.loggerLevel = nil
+	}
+}
+
+// BufferTrigger update the level at or above which held events are flushed. It defaults
+// to ErrorLevel.
+func BufferTrigger(level LogLevel) BufferOption {
+	return func(b *LogBuffer) {
+		b.trigger = level
+	}
+}
+
+// BufferMaxEvents update the maximum number of held events. Once reached, the oldest
+// events are dropped. It defaults to DefaultBufferMaxEvents.
+func BufferMaxEvents(maxEvents int) BufferOption {
+	return func(b *LogBuffer) {
+		b.maxEvents = maxEvents
+	}
+}
+
+// LogBuffer is the writer of a buffered logger, created by Logger.Buffered. It holds the
+// events below its threshold level in memory, writes the other ones and, when an event at
+// or above its trigger level occurs, flushes the held events first, in order. Events
+// below the threshold are then written as they come.
+// Held events are discarded with Discard, typically when the request ends successfully.
+type LogBuffer struct {
+	w           LevelWriter
+	threshold   LogLevel
+	loggerLevel func() LogLevel
+	trigger     LogLevel
+	maxEvents   int
+
+	mu        sync.Mutex
+	events    []bufferedEvent // ring of held events once maxEvents is reached
+	head      int             // index of the oldest held event
+	dropped   int
+	triggered bool
+	discarded bool
+	stop      chan struct{} // closed to stop watching the context of BufferCtx
+}
+
+type bufferedEvent struct {
+	level LogLevel
+	p     []byte
+}
+
+// Buffered returns a copy of the logger whose events below the threshold level are held
+// by the returned LogBuffer, until an event at or above the trigger level occurs or the
+// buffer is discarded. The copy keeps the level of the logger, including its AtomicLevel
+// and LevelFilter, but logs the debug level too so the buffer can hold its events.
+func (l Logger) Buffered(options ...BufferOption) (Logger, *LogBuffer) {
+	b := &LogBuffer{
+		w:           l.writer,
+		loggerLevel: l.effectiveLevel,
+		trigger:     ErrorLevel,
+		maxEvents:   DefaultBufferMaxEvents,
+	}
+	for _, option := range options {
+		option(b)
+	}
+	buffered := l.With(Writer(b))
+	buffered.buffered = true
+	return buffered, b
+}
+
+// BufferCtx returns a copy of ctx associated with a buffered copy of its logger (see
+// FromCtx and Logger.Buffered). The held events are discarded when ctx is done.
+//
+// If ctx can be canceled, a goroutine watches it until it's done or the buffer is flushed
+// or discarded: ctx must be canceled, or the buffer flushed or discarded, to end it.
+func BufferCtx(ctx context.Context, options ...BufferOption) (context.Context, *LogBuffer) {
+	logger, b := FromCtx(ctx).Buffered(options...)
+	if done := ctx.Done(); done != nil {
+		stop := make(chan struct{})
+		b.stop = stop
+		go func() {
+			select {
+			case <-done:
+				b.Discard()
+			case <-stop:
+			}
+		}()
+	}
+	return logger.ToCtx(ctx), b
+}
+
+// Write implements the io.Writer interface.
+func (b *LogBuffer) Write(p []byte) (n int, err error) {
+	return b.WriteLevel(NoLevel, p)
+}
+
+// WriteLevel implements the LevelWriter interface.
+func (b *LogBuffer) WriteLevel(level LogLevel, p []byte) (n int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if level.Severity() >= b.trigger.Severity() && level != NoLevel && !b.triggered {
+		b.triggered = true
+		if err = b.flush(); err != nil {
+			return 0, err
+		}
+	}
+	threshold := b.threshold
+	if b.loggerLevel != nil {
+		threshold = b.loggerLevel()
+	}
+	if level.Severity() >= threshold.Severity() {
+		return b.w.WriteLevel(level, p)
+	}
+	if b.discarded {
+		return len(p), nil
+	}
+	if b.triggered {
+		return b.w.WriteLevel(level, p)
+	}
+
+	if b.maxEvents > 0 && len(b.events) >= b.maxEvents {
+		// drop the oldest event, its memory is reused for p
+		event := &b.events[b.head]
+		event.level, event.p = level, append(event.p[:0], p...)
+		b.head = (b.head + 1) % len(b.events)
+		b.dropped++
+		return len(p), nil
+	}
+	// p is reused once written
+	b.events = append(b.events, bufferedEvent{level: level, p: append([]byte(nil), p...)})
+	return len(p), nil
+}
+
+// Flush writes the held events, and makes the buffer write the next ones as they come.
+func (b *LogBuffer) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.triggered = true
+	return b.flush()
+}
+
+// Discard drops the held events, and the next ones below the threshold level.
+func (b *LogBuffer) Discard() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = nil
+	b.head = 0
+	b.discarded = true
+	b.stopWatching()
+}
+
+// Dropped returns the number of events dropped because the buffer was full.
+func (b *LogBuffer) Dropped() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// flush writes the held events, from the oldest, once the buffer is triggered. b.mu must
+// be held.
+func (b *LogBuffer) flush() error {
+	b.stopWatching()
+	events, head := b.events, b.head
+	b.events, b.head = nil, 0
+	for _, events := range [][]bufferedEvent{events[head:], events[:head]} {
+		for _, event := range events {
+			if _, err := b.w.WriteLevel(event.level, event.p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stopWatching ends the goroutine watching the context of BufferCtx, if any. b.mu must
+// be held.
+func (b *LogBuffer) stopWatching() {
+	if b.stop != nil {
+		close(b.stop)
+		b.stop = nil
+	}
+}
@@ -0,0 +1,115 @@
+package rz
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestBuffered(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(Writer(out), Level(InfoLevel), Fields(Timestamp(false)))
+
+	buffered, buffer := log.Buffered(BufferMaxEvents(2))
+	buffered.Debug("1")
+	buffered.Info("2")
+	buffered.Debug("3")
+	buffered.Debug("4")
+	if got, want := out.String(), `{"level":"info","message":"2"}`+"\n"; got != want {
+		t.Errorf("invalid log output before trigger:\ngot:  %v\nwant: %v", got, want)
+	}
+	buffered.Error("5")
+	buffered.Debug("6")
+	want := `{"level":"info","message":"2"}
+{"level":"debug","message":"3"}
+{"level":"debug","message":"4"}
+{"level":"error","message":"5"}
+{"level":"debug","message":"6"}
+`
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output after trigger:\ngot:\n%v\nwant:\n%v", got, want)
+	}
+	if got := buffer.Dropped(); got != 1 {
+		t.Errorf("Dropped() = %d, want 1", got)
+	}
+
+	out.Reset()
+	buffered, buffer = log.Buffered(BufferThreshold(WarnLevel), BufferTrigger(FatalLevel))
+	buffered.Info("1")
+	buffered.Error("2")
+	buffer.Discard()
+	buffered.Debug("3")
+	if got, want := out.String(), `{"level":"error","message":"2"}`+"\n"; got != want {
+		t.Errorf("invalid log output after Discard:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestBufferedRing(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(Writer(out), Level(InfoLevel), Fields(Timestamp(false)))
+	buffered, buffer := log.Buffered(BufferMaxEvents(3))
+	for i := 0; i < 10; i++ {
+		buffered.Debug("", Int("i", i))
+	}
+	if err := buffer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"level":"debug","i":7}
+{"level":"debug","i":8}
+{"level":"debug","i":9}
+`
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:\n%v\nwant:\n%v", got, want)
+	}
+	if got := buffer.Dropped(); got != 7 {
+		t.Errorf("Dropped() = %d, want 7", got)
+	}
+}
+
+func TestBufferCtx(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(Writer(out), Level(InfoLevel), Fields(Timestamp(false)))
+	ctx, cancel := context.WithCancel(log.ToCtx(context.Background()))
+	defer cancel()
+
+	ctx, buffer := BufferCtx(ctx)
+	FromCtx(ctx).Debug("held")
+	if out.Len() != 0 {
+		t.Errorf("debug event written before flush: %s", out)
+	}
+	if err := buffer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), `{"level":"debug","message":"held"}`+"\n"; got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestBufferedLevelRef(t *testing.T) {
+	out := &bytes.Buffer{}
+	ref := NewAtomicLevel(InfoLevel)
+	log := New(Writer(out), LevelRef(ref), Fields(Timestamp(false)))
+
+	buffered, _ := log.Buffered()
+	if got := buffered.GetLevel(); got != InfoLevel {
+		t.Errorf("GetLevel() = %v, want %v", got, InfoLevel)
+	}
+	buffered.Debug("held")
+	buffered.Trace("dropped")
+	if out.Len() != 0 {
+		t.Errorf("debug event written before the level change: %s", out)
+	}
+
+	ref.SetLevel(DebugLevel)
+	buffered.Debug("written")
+	if got, want := out.String(), `{"level":"debug","message":"written"}`+"\n"; got != want {
+		t.Errorf("invalid log output after the level change:\ngot:  %v\nwant: %v", got, want)
+	}
+
+	out.Reset()
+	ref.SetLevel(TraceLevel)
+	buffered.Trace("trace")
+	if got, want := out.String(), `{"level":"trace","message":"trace"}`+"\n"; got != want {
+		t.Errorf("invalid log output with trace level:\ngot:  %v\nwant: %v", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+// Package cbor provides a binary rz.Encoder using the CBOR format (RFC 8949),
+// and a decoder to convert CBOR encoded events back into JSON.
+//
+//	logger := rz.New(rz.WithEncoder(cbor.Encoder{}))
+package cbor
+
+import "math"
+
+// Encoder is the CBOR encoder
+type Encoder struct{}
+
+const (
+	majorOffset = 5
+
+	majorTypeUnsignedInt    = byte(0 << majorOffset)
+	majorTypeNegativeInt    = byte(1 << majorOffset)
+	majorTypeByteString     = byte(2 << majorOffset)
+	majorTypeUtf8String     = byte(3 << majorOffset)
+	majorTypeArray          = byte(4 << majorOffset)
+	majorTypeMap            = byte(5 << majorOffset)
+	majorTypeTags           = byte(6 << majorOffset)
+	majorTypeSimpleAndFloat = byte(7 << majorOffset)
+
+	majorTypeMask      = byte(7 << majorOffset)
+	additionalTypeMask = byte(0x1f)
+
+	additionalMax                  = 23
+	additionalTypeIntUint8         = 24
+	additionalTypeIntUint16        = 25
+	additionalTypeIntUint32        = 26
+	additionalTypeIntUint64        = 27
+	additionalTypeInfiniteCount    = 31
+	additionalTypeBoolFalse        = 20
+	additionalTypeBoolTrue         = 21
+	additionalTypeNull             = 22
+	additionalTypeUndefined        = 23
+	additionalTypeFloat16          = 25
+	additionalTypeFloat32          = 26
+	additionalTypeFloat64          = 27
+	additionalTypeBreak            = 31
+	additionalTypeTagDateTime      = 0
+	additionalTypeTagEpochTime     = 1
+	additionalTypeTagBase16        = 23
+	additionalTypeTagNetworkAddr   = 260
+	additionalTypeTagNetworkPrefix = 261
+	additionalTypeTagEmbeddedJSON  = 262
+	additionalTypeTagDuration      = 1002
+
+	durationKeySeconds     = 1
+	durationKeyNanoseconds = -9
+
+	byteBreak = majorTypeSimpleAndFloat | additionalTypeBreak
+)
+
+// AppendKey appends a new key to the output CBOR.
+func (e Encoder) AppendKey(dst []byte, key string) []byte {
+	return e.AppendString(dst, key)
+}
+
+// appendCborTypePrefix appends the head of a data item: its major type and
+// its argument, using the shortest possible encoding.
+func appendCborTypePrefix(dst []byte, major byte, number uint64) []byte {
+	switch {
+	case number <= additionalMax:
+		return append(dst, major|byte(number))
+	case number <= math.MaxUint8:
+		return append(dst, major|additionalTypeIntUint8, byte(number))
+	case number <= math.MaxUint16:
+		return append(dst, major|additionalTypeIntUint16,
+			byte(number>>8), byte(number))
+	case number <= math.MaxUint32:
+		return append(dst, major|additionalTypeIntUint32,
+			byte(number>>24), byte(number>>16), byte(number>>8), byte(number))
+	}
+	return append(dst, major|additionalTypeIntUint64,
+		byte(number>>56), byte(number>>48), byte(number>>40), byte(number>>32),
+		byte(number>>24), byte(number>>16), byte(number>>8), byte(number))
+}
@@ -0,0 +1,22 @@
+package cbor
+
+// AppendBytes encodes the input bytes to cbor as a byte string and appends
+// the encoded bytes to the input byte slice.
+func (Encoder) AppendBytes(dst, s []byte) []byte {
+	dst = appendCborTypePrefix(dst, majorTypeByteString, uint64(len(s)))
+	return append(dst, s...)
+}
+
+// AppendHex appends the input bytes as a byte string tagged for an expected
+// conversion to hex (base16) when decoded.
+func (e Encoder) AppendHex(dst, s []byte) []byte {
+	dst = appendCborTypePrefix(dst, majorTypeTags, additionalTypeTagBase16)
+	return e.AppendBytes(dst, s)
+}
+
+// AppendEmbeddedJSON adds already encoded JSON to dst, as a byte string
+// tagged as embedded JSON.
+func (e Encoder) AppendEmbeddedJSON(dst, j []byte) []byte {
+	dst = appendCborTypePrefix(dst, majorTypeTags, additionalTypeTagEmbeddedJSON)
+	return e.AppendBytes(dst, j)
+}
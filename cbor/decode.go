@@ -0,0 +1,447 @@
+package cbor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/skerkour/rz/internal/json"
+)
+
+var (
+	jsonEnc = json.Encoder{}
+
+	errUnexpectedBreak = errors.New("cbor: unexpected break")
+)
+
+// Decoder reads CBOR encoded events from an input stream and converts
+// them to JSON.
+type Decoder struct {
+	r *bufio.Reader
+
+	// DurationFieldUnit is the unit durations are rendered with.
+	// It should match rz.DurationFieldUnit of the encoding logger.
+	DurationFieldUnit time.Duration
+	// DurationFieldInteger renders durations as integer instead of float if set to true.
+	// It should match rz.DurationFieldInteger of the encoding logger.
+	DurationFieldInteger bool
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r:                 bufio.NewReader(r),
+		DurationFieldUnit: time.Millisecond,
+	}
+}
+
+// Decode reads the next CBOR data item from its input and appends its JSON
+// representation to dst. It returns io.EOF when the input is exhausted.
+func (d *Decoder) Decode(dst []byte) ([]byte, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return dst, err
+	}
+	dst, err := d.decodeItem(dst)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return dst, err
+}
+
+// Transcode converts the stream of CBOR encoded events read from src into
+// JSON lines written to dst, until src is exhausted.
+func Transcode(dst io.Writer, src io.Reader) error {
+	d := NewDecoder(src)
+	var buf []byte
+	for {
+		var err error
+		buf, err = d.Decode(buf[:0])
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err = dst.Write(append(buf, '\n')); err != nil {
+			return err
+		}
+	}
+}
+
+// DecodeToJSON converts in, a sequence of CBOR encoded events, to JSON lines.
+func DecodeToJSON(in []byte) ([]byte, error) {
+	var out bytes.Buffer
+	err := Transcode(&out, bytes.NewReader(in))
+	return out.Bytes(), err
+}
+
+func (d *Decoder) decodeItem(dst []byte) ([]byte, error) {
+	head, err := d.r.ReadByte()
+	if err != nil {
+		return dst, err
+	}
+	if head == byteBreak {
+		return dst, errUnexpectedBreak
+	}
+	return d.decodeItemWithHead(dst, head)
+}
+
+func (d *Decoder) decodeItemWithHead(dst []byte, head byte) ([]byte, error) {
+	major, info := head&majorTypeMask, head&additionalTypeMask
+	if major == majorTypeSimpleAndFloat {
+		return d.decodeSimpleAndFloat(dst, info)
+	}
+	if info == additionalTypeInfiniteCount {
+		switch major {
+		case majorTypeByteString, majorTypeUtf8String:
+			s, err := d.readIndefiniteString(major)
+			if err != nil {
+				return dst, err
+			}
+			if major == majorTypeUtf8String {
+				return jsonEnc.AppendString(dst, string(s)), nil
+			}
+			return jsonEnc.AppendBytes(dst, s), nil
+		case majorTypeArray:
+			return d.decodeArray(dst, -1)
+		case majorTypeMap:
+			return d.decodeMap(dst, -1)
+		}
+		return dst, fmt.Errorf("cbor: invalid indefinite length for major type %d", major>>majorOffset)
+	}
+	n, err := d.readArgument(info)
+	if err != nil {
+		return dst, err
+	}
+	switch major {
+	case majorTypeUnsignedInt:
+		return jsonEnc.AppendUint64(dst, n), nil
+	case majorTypeNegativeInt:
+		if n > math.MaxInt64 {
+			// -1-n does not fit in an int64.
+			return strconv.AppendFloat(dst, -1-float64(n), 'f', -1, 64), nil
+		}
+		return jsonEnc.AppendInt64(dst, -1-int64(n)), nil
+	case majorTypeByteString:
+		b, err := d.readN(n)
+		if err != nil {
+			return dst, err
+		}
+		return jsonEnc.AppendBytes(dst, b), nil
+	case majorTypeUtf8String:
+		b, err := d.readN(n)
+		if err != nil {
+			return dst, err
+		}
+		return jsonEnc.AppendString(dst, string(b)), nil
+	case majorTypeArray:
+		return d.decodeArray(dst, int64(n))
+	case majorTypeMap:
+		return d.decodeMap(dst, int64(n))
+	}
+	return d.decodeTag(dst, n)
+}
+
+func (d *Decoder) decodeSimpleAndFloat(dst []byte, info byte) ([]byte, error) {
+	switch info {
+	case additionalTypeBoolFalse:
+		return jsonEnc.AppendBool(dst, false), nil
+	case additionalTypeBoolTrue:
+		return jsonEnc.AppendBool(dst, true), nil
+	case additionalTypeNull, additionalTypeUndefined:
+		return jsonEnc.AppendNil(dst), nil
+	case additionalTypeFloat16:
+		n, err := d.readArgument(additionalTypeIntUint16)
+		if err != nil {
+			return dst, err
+		}
+		return jsonEnc.AppendFloat32(dst, float16ToFloat32(uint16(n))), nil
+	case additionalTypeFloat32:
+		n, err := d.readArgument(additionalTypeIntUint32)
+		if err != nil {
+			return dst, err
+		}
+		return jsonEnc.AppendFloat32(dst, math.Float32frombits(uint32(n))), nil
+	case additionalTypeFloat64:
+		n, err := d.readArgument(additionalTypeIntUint64)
+		if err != nil {
+			return dst, err
+		}
+		return jsonEnc.AppendFloat64(dst, math.Float64frombits(n)), nil
+	}
+	return dst, fmt.Errorf("cbor: unsupported simple value %d", info)
+}
+
+// decodeArray decodes n array elements, or elements up to a break if n is negative.
+func (d *Decoder) decodeArray(dst []byte, n int64) ([]byte, error) {
+	dst = append(dst, '[')
+	for i := int64(0); n < 0 || i < n; i++ {
+		head, err := d.r.ReadByte()
+		if err != nil {
+			return dst, err
+		}
+		if head == byteBreak && n < 0 {
+			break
+		}
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		if dst, err = d.decodeItemWithHead(dst, head); err != nil {
+			return dst, err
+		}
+	}
+	return append(dst, ']'), nil
+}
+
+// decodeMap decodes n key/value pairs, or pairs up to a break if n is negative.
+func (d *Decoder) decodeMap(dst []byte, n int64) ([]byte, error) {
+	dst = append(dst, '{')
+	for i := int64(0); n < 0 || i < n; i++ {
+		head, err := d.r.ReadByte()
+		if err != nil {
+			return dst, err
+		}
+		if head == byteBreak && n < 0 {
+			break
+		}
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		if head&majorTypeMask == majorTypeUtf8String {
+			dst, err = d.decodeItemWithHead(dst, head)
+		} else {
+			// JSON keys must be strings: quote the JSON form of the key.
+			var key []byte
+			key, err = d.decodeItemWithHead(nil, head)
+			dst = jsonEnc.AppendString(dst, string(key))
+		}
+		if err != nil {
+			return dst, err
+		}
+		dst = append(dst, ':')
+		if dst, err = d.decodeItem(dst); err != nil {
+			return dst, err
+		}
+	}
+	return append(dst, '}'), nil
+}
+
+func (d *Decoder) decodeTag(dst []byte, tag uint64) ([]byte, error) {
+	switch tag {
+	case additionalTypeTagBase16:
+		b, err := d.readByteString()
+		if err != nil {
+			return dst, err
+		}
+		return jsonEnc.AppendHex(dst, b), nil
+	case additionalTypeTagEmbeddedJSON:
+		b, err := d.readByteString()
+		if err != nil {
+			return dst, err
+		}
+		return jsonEnc.AppendEmbeddedJSON(dst, b), nil
+	case additionalTypeTagNetworkAddr:
+		b, err := d.readByteString()
+		if err != nil {
+			return dst, err
+		}
+		if len(b) == net.IPv4len || len(b) == net.IPv6len {
+			return jsonEnc.AppendIPAddr(dst, net.IP(b)), nil
+		}
+		return jsonEnc.AppendMACAddr(dst, net.HardwareAddr(b)), nil
+	case additionalTypeTagNetworkPrefix:
+		return d.decodeIPPrefix(dst)
+	case additionalTypeTagDuration:
+		return d.decodeDuration(dst)
+	}
+	// Date/time strings, epoch-based date/times and unknown tags are
+	// rendered as their content.
+	return d.decodeItem(dst)
+}
+
+func (d *Decoder) decodeIPPrefix(dst []byte) ([]byte, error) {
+	n, err := d.readHead(majorTypeMap)
+	if err != nil {
+		return dst, err
+	}
+	if n != 1 {
+		return dst, fmt.Errorf("cbor: invalid network prefix map length %d", n)
+	}
+	ip, err := d.readByteString()
+	if err != nil {
+		return dst, err
+	}
+	ones, err := d.readHead(majorTypeUnsignedInt)
+	if err != nil {
+		return dst, err
+	}
+	pfx := net.IPNet{IP: ip, Mask: net.CIDRMask(int(ones), len(ip)*8)}
+	return jsonEnc.AppendIPPrefix(dst, pfx), nil
+}
+
+func (d *Decoder) decodeDuration(dst []byte) ([]byte, error) {
+	n, err := d.readHead(majorTypeMap)
+	if err != nil {
+		return dst, err
+	}
+	var dur time.Duration
+	for i := uint64(0); i < n; i++ {
+		key, err := d.readInt()
+		if err != nil {
+			return dst, err
+		}
+		val, err := d.readInt()
+		if err != nil {
+			return dst, err
+		}
+		switch key {
+		case durationKeySeconds:
+			dur += time.Duration(val) * time.Second
+		case durationKeyNanoseconds:
+			dur += time.Duration(val)
+		default:
+			return dst, fmt.Errorf("cbor: unsupported duration key %d", key)
+		}
+	}
+	return jsonEnc.AppendDuration(dst, dur, d.DurationFieldUnit, d.DurationFieldInteger), nil
+}
+
+// readHead reads the head of a data item which must be of the given major type,
+// and returns its argument.
+func (d *Decoder) readHead(major byte) (uint64, error) {
+	head, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if head&majorTypeMask != major {
+		return 0, fmt.Errorf("cbor: unexpected major type %d, want %d", head>>majorOffset, major>>majorOffset)
+	}
+	return d.readArgument(head & additionalTypeMask)
+}
+
+func (d *Decoder) readInt() (int64, error) {
+	head, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	n, err := d.readArgument(head & additionalTypeMask)
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt64 {
+		return 0, errors.New("cbor: integer overflow")
+	}
+	switch head & majorTypeMask {
+	case majorTypeUnsignedInt:
+		return int64(n), nil
+	case majorTypeNegativeInt:
+		return -1 - int64(n), nil
+	}
+	return 0, fmt.Errorf("cbor: unexpected major type %d, want an integer", head>>majorOffset)
+}
+
+func (d *Decoder) readByteString() ([]byte, error) {
+	head, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if head&majorTypeMask != majorTypeByteString {
+		return nil, fmt.Errorf("cbor: unexpected major type %d, want a byte string", head>>majorOffset)
+	}
+	if head&additionalTypeMask == additionalTypeInfiniteCount {
+		return d.readIndefiniteString(majorTypeByteString)
+	}
+	n, err := d.readArgument(head & additionalTypeMask)
+	if err != nil {
+		return nil, err
+	}
+	return d.readN(n)
+}
+
+// readIndefiniteString concatenates the chunks of an indefinite length string.
+func (d *Decoder) readIndefiniteString(major byte) ([]byte, error) {
+	var s []byte
+	for {
+		head, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if head == byteBreak {
+			return s, nil
+		}
+		if head&majorTypeMask != major {
+			return nil, fmt.Errorf("cbor: invalid chunk of major type %d in indefinite length string", head>>majorOffset)
+		}
+		n, err := d.readArgument(head & additionalTypeMask)
+		if err != nil {
+			return nil, err
+		}
+		chunk, err := d.readN(n)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, chunk...)
+	}
+}
+
+func (d *Decoder) readArgument(info byte) (uint64, error) {
+	var size int
+	switch {
+	case info <= additionalMax:
+		return uint64(info), nil
+	case info == additionalTypeIntUint8:
+		size = 1
+	case info == additionalTypeIntUint16:
+		size = 2
+	case info == additionalTypeIntUint32:
+		size = 4
+	case info == additionalTypeIntUint64:
+		size = 8
+	default:
+		return 0, fmt.Errorf("cbor: invalid additional type %d", info)
+	}
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[:size]); err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, b := range buf[:size] {
+		n = n<<8 | uint64(b)
+	}
+	return n, nil
+}
+
+func (d *Decoder) readN(n uint64) ([]byte, error) {
+	if n > math.MaxInt32 {
+		return nil, fmt.Errorf("cbor: string of %d bytes is too long", n)
+	}
+	b := make([]byte, n)
+	_, err := io.ReadFull(d.r, b)
+	return b, err
+}
+
+// float16ToFloat32 converts an IEEE 754 half-precision float to a float32.
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+	switch exp {
+	case 0:
+		// Zero or subnormal number.
+		f := float32(frac) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case 0x1f:
+		// Infinity or NaN.
+		return math.Float32frombits(sign | 0xff<<23 | frac<<13)
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | frac<<13)
+}
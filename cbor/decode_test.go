@@ -0,0 +1,98 @@
+package cbor
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/skerkour/rz/internal/json"
+)
+
+func TestDecodeToJSON(t *testing.T) {
+	jenc := json.Encoder{}
+	ts := time.Date(2001, 2, 3, 4, 5, 6, 7, time.UTC)
+	appendEvent := func(dst []byte, useJSON bool) []byte {
+		type encoder interface {
+			AppendBeginMarker(dst []byte) []byte
+			AppendEndMarker(dst []byte) []byte
+			AppendLineBreak(dst []byte) []byte
+			AppendKey(dst []byte, key string) []byte
+			AppendString(dst []byte, s string) []byte
+			AppendInt(dst []byte, val int) []byte
+			AppendFloats64(dst []byte, vals []float64) []byte
+			AppendTime(dst []byte, t time.Time, format string) []byte
+			AppendTimes(dst []byte, vals []time.Time, format string) []byte
+			AppendDuration(dst []byte, d time.Duration, unit time.Duration, useInt bool) []byte
+			AppendDurations(dst []byte, vals []time.Duration, unit time.Duration, useInt bool) []byte
+			AppendEmbeddedJSON(dst, j []byte) []byte
+			AppendInterface(dst []byte, i interface{}) []byte
+			AppendIPPrefix(dst []byte, pfx net.IPNet) []byte
+			AppendArrayStart(dst []byte) []byte
+			AppendArrayDelim(dst []byte) []byte
+			AppendArrayEnd(dst []byte) []byte
+			AppendNil(dst []byte) []byte
+			AppendBool(dst []byte, val bool) []byte
+		}
+		var e encoder = enc
+		if useJSON {
+			e = jenc
+		}
+		dst = e.AppendBeginMarker(dst)
+		dst = e.AppendString(e.AppendKey(dst, "string"), "foo")
+		dst = e.AppendInt(e.AppendKey(dst, "negative"), -42)
+		dst = e.AppendFloats64(e.AppendKey(dst, "floats"), []float64{1, 2.5})
+		dst = e.AppendTime(e.AppendKey(dst, "time"), ts, time.RFC3339)
+		dst = e.AppendTime(e.AppendKey(dst, "time_nano"), ts, time.RFC3339Nano)
+		dst = e.AppendTime(e.AppendKey(dst, "time_unix"), ts, "")
+		dst = e.AppendTime(e.AppendKey(dst, "time_kitchen"), ts, time.Kitchen)
+		dst = e.AppendTimes(e.AppendKey(dst, "times"), []time.Time{ts, ts}, time.RFC3339)
+		dst = e.AppendDuration(e.AppendKey(dst, "dur"), 1500*time.Microsecond, time.Millisecond, false)
+		dst = e.AppendDuration(e.AppendKey(dst, "dur_int"), 1500*time.Microsecond, time.Millisecond, true)
+		dst = e.AppendDurations(e.AppendKey(dst, "durs"), []time.Duration{-1500 * time.Millisecond, time.Hour}, time.Millisecond, false)
+		dst = e.AppendEmbeddedJSON(e.AppendKey(dst, "json"), []byte(`{"some":["json"]}`))
+		dst = e.AppendInterface(e.AppendKey(dst, "any"), map[string]int{"a": 1})
+		dst = e.AppendIPPrefix(e.AppendKey(dst, "net"), net.IPNet{IP: net.IP{10, 0, 0, 1}, Mask: net.CIDRMask(8, 32)})
+		arr := e.AppendNil(e.AppendArrayDelim(nil))
+		arr = e.AppendBool(e.AppendArrayDelim(arr), true)
+		dst = append(e.AppendArrayStart(e.AppendKey(dst, "array")), arr...)
+		dst = e.AppendArrayEnd(dst)
+		dst = e.AppendEndMarker(dst)
+		return e.AppendLineBreak(dst)
+	}
+
+	var cborStream, jsonStream []byte
+	for i := 0; i < 3; i++ {
+		cborStream = appendEvent(cborStream, false)
+		jsonStream = appendEvent(jsonStream, true)
+	}
+
+	got, err := DecodeToJSON(cborStream)
+	if err != nil {
+		t.Fatalf("DecodeToJSON() returned error: %s", err)
+	}
+	if string(got) != string(jsonStream) {
+		t.Errorf("DecodeToJSON()\ngot:  %s\nwant: %s", got, jsonStream)
+	}
+}
+
+func TestDecodeDurationUnit(t *testing.T) {
+	in := enc.AppendDuration(nil, 2500*time.Millisecond, time.Millisecond, false)
+	d := NewDecoder(bytes.NewReader(in))
+	d.DurationFieldUnit = time.Second
+	d.DurationFieldInteger = true
+	got, err := d.Decode(nil)
+	if err != nil {
+		t.Fatalf("Decode() returned error: %s", err)
+	}
+	if want := "2"; string(got) != want {
+		t.Errorf("Decode() = %s, want %s", got, want)
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	in := enc.AppendString(enc.AppendKey(enc.AppendBeginMarker(nil), "foo"), "bar")
+	if _, err := DecodeToJSON(in); err == nil {
+		t.Error("DecodeToJSON() of a truncated event returned no error")
+	}
+}
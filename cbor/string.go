@@ -0,0 +1,18 @@
+package cbor
+
+// AppendStrings encodes the input strings to cbor and
+// appends the encoded string list to the input byte slice.
+func (e Encoder) AppendStrings(dst []byte, vals []string) []byte {
+	dst = appendCborTypePrefix(dst, majorTypeArray, uint64(len(vals)))
+	for _, val := range vals {
+		dst = e.AppendString(dst, val)
+	}
+	return dst
+}
+
+// AppendString encodes the input string to cbor as a text string and appends
+// the encoded string to the input byte slice.
+func (Encoder) AppendString(dst []byte, s string) []byte {
+	dst = appendCborTypePrefix(dst, majorTypeUtf8String, uint64(len(s)))
+	return append(dst, s...)
+}
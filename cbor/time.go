@@ -0,0 +1,70 @@
+package cbor
+
+import (
+	"time"
+)
+
+// AppendTime encodes the input time and appends it to the input byte slice.
+//
+// If format is empty, the time is encoded as an integer UNIX timestamp tagged
+// as an epoch-based date/time. If format is time.RFC3339 or time.RFC3339Nano,
+// the formatted string is tagged as a standard date/time string. Any other
+// format produces a plain text string.
+func (e Encoder) AppendTime(dst []byte, t time.Time, format string) []byte {
+	switch format {
+	case "":
+		dst = appendCborTypePrefix(dst, majorTypeTags, additionalTypeTagEpochTime)
+		return e.AppendInt64(dst, t.Unix())
+	case time.RFC3339, time.RFC3339Nano:
+		dst = appendCborTypePrefix(dst, majorTypeTags, additionalTypeTagDateTime)
+	}
+	var buf [64]byte
+	b := t.AppendFormat(buf[:0], format)
+	dst = appendCborTypePrefix(dst, majorTypeUtf8String, uint64(len(b)))
+	return append(dst, b...)
+}
+
+// AppendTimes encodes the input times with the given format
+// and appends the encoded list to the input byte slice.
+func (e Encoder) AppendTimes(dst []byte, vals []time.Time, format string) []byte {
+	dst = appendCborTypePrefix(dst, majorTypeArray, uint64(len(vals)))
+	for _, t := range vals {
+		dst = e.AppendTime(dst, t, format)
+	}
+	return dst
+}
+
+// AppendDuration encodes the input duration and appends it to the input
+// byte slice.
+//
+// Durations are tagged (RFC 9581) maps holding whole seconds and, if any,
+// remaining nanoseconds. unit is only used to truncate the duration when
+// useInt is true: rendering in unit is left to the decoder.
+func (e Encoder) AppendDuration(dst []byte, d time.Duration, unit time.Duration, useInt bool) []byte {
+	if useInt {
+		d = d / unit * unit
+	}
+	sec, nsec := int64(d/time.Second), int64(d%time.Second)
+	if nsec < 0 {
+		sec--
+		nsec += int64(time.Second)
+	}
+	dst = appendCborTypePrefix(dst, majorTypeTags, additionalTypeTagDuration)
+	if nsec == 0 {
+		dst = appendCborTypePrefix(dst, majorTypeMap, 1)
+		return e.AppendInt64(e.AppendInt(dst, durationKeySeconds), sec)
+	}
+	dst = appendCborTypePrefix(dst, majorTypeMap, 2)
+	dst = e.AppendInt64(e.AppendInt(dst, durationKeySeconds), sec)
+	return e.AppendInt64(e.AppendInt(dst, durationKeyNanoseconds), nsec)
+}
+
+// AppendDurations encodes the input durations and appends the encoded list
+// to the input byte slice.
+func (e Encoder) AppendDurations(dst []byte, vals []time.Duration, unit time.Duration, useInt bool) []byte {
+	dst = appendCborTypePrefix(dst, majorTypeArray, uint64(len(vals)))
+	for _, d := range vals {
+		dst = e.AppendDuration(dst, d, unit, useInt)
+	}
+	return dst
+}
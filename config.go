@@ -20,27 +20,71 @@ func Writer(writer io.Writer) LoggerOption {
 			lw = levelWriterAdapter{writer}
 		}
 		logger.writer = lw
+		logger.buffered = false
+		ew, ok := lw.(eventWriter)
+		logger.writerEntry = ok && ew.usesEntry()
 	}
 }
 
-// Level update logger's level.
+// Level update logger's level. The logger stops following the AtomicLevel set
+// with LevelRef, if any.
 func Level(lvl LogLevel) LoggerOption {
 	return func(logger *Logger) {
 		logger.level = lvl
+		logger.levelRef = nil
 	}
 }
 
-// Sampler update logger's sampler.
+// LevelRef makes the logger read its level from ref, so it can be changed at runtime.
+// Loggers derived with With share ref.
+func LevelRef(ref *AtomicLevel) LoggerOption {
+	return func(logger *Logger) {
+		logger.levelRef = ref
+	}
+}
+
+// Filter makes the logger resolve its level from filter, according to its name.
+// Loggers derived with With or Named share filter.
+func Filter(filter *LevelFilter) LoggerOption {
+	return func(logger *Logger) {
+		logger.filter = filter
+	}
+}
+
+// Sampler update logger's sampler. If sampler implements EventSampler, its SampleEvent
+// method is used instead of Sample.
 func Sampler(sampler LogSampler) LoggerOption {
 	return func(logger *Logger) {
 		logger.sampler = sampler
+		logger.eventSampler, _ = sampler.(EventSampler)
+		logger.sampleEntry = false
+		if s, ok := sampler.(entrySampler); ok {
+			logger.sampleEntry = s.usesEntry()
+		}
+	}
+}
+
+// WithSamplingStats makes the logger count the events it considers, keeps and drops in
+// stats. Loggers derived with With or Named share stats.
+func WithSamplingStats(stats *SamplingStats) LoggerOption {
+	return func(logger *Logger) {
+		logger.stats = stats
 	}
 }
 
 // AddHook appends hook to logger's hook
 func AddHook(hook LogHook) LoggerOption {
 	return func(logger *Logger) {
-		logger.hooks = append(logger.hooks, hook)
+		logger.addHook(hook, 0)
+	}
+}
+
+// AddHookPriority adds hook to logger's hooks with priority. Hooks run by decreasing
+// priority, then in the order they were added. Hooks added with AddHook or Hooks have
+// priority 0, so hooks with a negative priority run after them.
+func AddHookPriority(hook LogHook, priority int) LoggerOption {
+	return func(logger *Logger) {
+		logger.addHook(hook, priority)
 	}
 }
 
@@ -48,18 +92,51 @@ func AddHook(hook LogHook) LoggerOption {
 func Hooks(hooks ...LogHook) LoggerOption {
 	return func(logger *Logger) {
 		logger.hooks = hooks
+		logger.hookPriorities = nil
+	}
+}
+
+// AddPostHook appends hook to logger's post hooks, which run after each event is written,
+// whether the write succeeded or not, with the event's final bytes.
+// Events discarded by hooks are not passed to post hooks.
+func AddPostHook(hook LogPostHook) LoggerOption {
+	return func(logger *Logger) {
+		logger.addPostHook(hook, 0)
+	}
+}
+
+// AddPostHookPriority adds hook to logger's post hooks with priority, ordered as
+// AddHookPriority orders hooks.
+func AddPostHookPriority(hook LogPostHook, priority int) LoggerOption {
+	return func(logger *Logger) {
+		logger.addPostHook(hook, priority)
+	}
+}
+
+// PostHooks replaces logger's post hooks
+func PostHooks(hooks ...LogPostHook) LoggerOption {
+	return func(logger *Logger) {
+		logger.setPostHooks(hooks)
+		logger.postHookPriorities = nil
 	}
 }
 
 // Fields update logger's context fields
 func Fields(fields ...Field) LoggerOption {
 	return func(logger *Logger) {
-		e := newEvent(logger.writer, logger.level)
+		e := newEvent(logger.writer, logger.level, logger.encoder)
 		e.buf = nil
 		copyInternalLoggerFieldsToEvent(logger, e)
+		e.withEntry = true
+		// the records and their data are kept by the logger
+		e.records, e.recordData = nil, nil
+		e.fieldStart = 0
 		for i := range fields {
 			fields[i](e)
 		}
+		if logger.redactor != nil {
+			logger.redactor.redactEvent(e, 0)
+		}
 		if e.stack != logger.stack {
 			logger.stack = e.stack
 		}
@@ -70,7 +147,22 @@ func Fields(fields ...Field) LoggerOption {
 			logger.timestamp = e.timestamp
 		}
 		if e.buf != nil {
-			logger.context = enc.AppendObjectData(logger.context, e.buf)
+			logger.context, logger.contextRecords = appendContextData(e.encoder, logger.context,
+				logger.contextRecords, e.buf, e.records)
+		}
+	}
+}
+
+// Redact makes the logger redact the fields of its events with redactor, including its
+// context fields. A nil redactor disables the redaction.
+func Redact(redactor *Redactor) LoggerOption {
+	return func(logger *Logger) {
+		if redactor == logger.redactor {
+			return
+		}
+		logger.redactor = redactor
+		if redactor != nil {
+			logger.redactContext()
 		}
 	}
 }
@@ -82,6 +174,15 @@ func Formatter(formatter LogFormatter) LoggerOption {
 	}
 }
 
+// WithEncoder update logger's encoder.
+// Context fields are encoded as soon as they are added, so this option should be
+// applied before Fields.
+func WithEncoder(encoder Encoder) LoggerOption {
+	return func(logger *Logger) {
+		logger.encoder = encoder
+	}
+}
+
 // TimestampFieldName update logger's timestampFieldName.
 func TimestampFieldName(timestampFieldName string) LoggerOption {
 	return func(logger *Logger) {
@@ -96,6 +197,14 @@ func LevelFieldName(levelFieldName string) LoggerOption {
 	}
 }
 
+// LevelFieldFormat update the format of logger's level field, e.g. LevelFormatBunyan to
+// encode levels as Bunyan and pino numbers.
+func LevelFieldFormat(format LevelFormat) LoggerOption {
+	return func(logger *Logger) {
+		logger.levelFormat = format
+	}
+}
+
 // MessageFieldName update logger's messageFieldName.
 func MessageFieldName(messageFieldName string) LoggerOption {
 	return func(logger *Logger) {
@@ -117,6 +226,14 @@ func CallerFieldName(callerFieldName string) LoggerOption {
 	}
 }
 
+// ComponentFieldName update logger's componentFieldName. Set an empty string to disable
+// the field.
+func ComponentFieldName(componentFieldName string) LoggerOption {
+	return func(logger *Logger) {
+		logger.componentFieldName = componentFieldName
+	}
+}
+
 // CallerSkipFrameCount update logger's callerSkipFrameCount.
 func CallerSkipFrameCount(callerSkipFrameCount int) LoggerOption {
 	return func(logger *Logger) {
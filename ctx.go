@@ -8,21 +8,27 @@ type ctxKey struct{}
 
 // ToCtx returns a copy of ctx with l associated. If an instance of Logger
 // is already in the context, the context is not updated.
+// If ctx holds a sampling decision (see TraceSampler), a copy of l applying it is
+// associated instead.
 func (l *Logger) ToCtx(ctx context.Context) context.Context {
 	if lp, ok := ctx.Value(ctxKey{}).(*Logger); ok {
 		if lp == l {
 			// Do not store same logger.
 			return ctx
 		}
-	} else if l.level == Disabled {
+	} else if l.GetLevel() == Disabled {
 		// Do not store disabled logger.
 		return ctx
 	}
+	if decision, ok := ctx.Value(traceCtxKey{}).(*traceDecision); ok && l.trace != decision {
+		return withTraceLogger(ctx, l, decision)
+	}
 	return context.WithValue(ctx, ctxKey{}, l)
 }
 
 // FromCtx returns the Logger associated with the ctx. If no logger
 // is associated, a New() logger is returned with a addedfield "rz.FromCtx": "error".
+// The logger applies the sampling decision held by ctx, if any (see TraceSampler).
 //
 // For example, to add a field to an existing logger in the context, use this
 // notation:
@@ -32,6 +38,7 @@ func (l *Logger) ToCtx(ctx context.Context) context.Context {
 //     l.With(...)
 func FromCtx(ctx context.Context) *Logger {
 	if l, ok := ctx.Value(ctxKey{}).(*Logger); ok {
+		// ToCtx and TraceSampler store loggers already applying the context's decision
 		return l
 	}
 	logger := New().With(Fields(String("rz.FromCtx", "error")))
@@ -18,6 +18,17 @@ const (
 	// DefaultCallerFieldName is the default field name used for caller field.
 	DefaultCallerFieldName = "caller"
 
+	// DefaultComponentFieldName is the default field name used for the name of named loggers.
+	DefaultComponentFieldName = "component"
+
+	// DefaultSampleRateFieldName is the default field name used by adaptive samplers for the
+	// sample rate of kept events.
+	DefaultSampleRateFieldName = "sample_rate"
+
+	// DefaultRateLimitedFieldName is the default field name used by token bucket samplers for
+	// the number of events denied since the previous allowed one.
+	DefaultRateLimitedFieldName = "rate_limited"
+
 	// DefaultCallerSkipFrameCount is the default number of stack frames to skip to find the caller.
 	DefaultCallerSkipFrameCount = 3
 
@@ -6,6 +6,10 @@ import (
 )
 
 // Encoder is used to serialize an object to be logged
+//
+// Encoders can also implement an AppendEmbeddedJSON(dst, j []byte) []byte method, adding
+// already encoded JSON to dst, e.g. for RawJSON fields. Otherwise, the JSON is appended
+// with AppendBytes.
 type Encoder interface {
 	AppendArrayDelim(dst []byte) []byte
 	AppendArrayEnd(dst []byte) []byte
@@ -55,3 +59,17 @@ type Encoder interface {
 	AppendUints64(dst []byte, vals []uint64) []byte
 	AppendUints8(dst []byte, vals []uint8) []byte
 }
+
+// embeddedJSONAppender is implemented by the encoders which can embed already encoded
+// JSON, e.g. JSONEncoder, cbor.Encoder and logfmt.Encoder.
+type embeddedJSONAppender interface {
+	AppendEmbeddedJSON(dst, j []byte) []byte
+}
+
+// appendEmbeddedJSON adds already encoded JSON to dst with enc.
+func appendEmbeddedJSON(enc Encoder, dst, j []byte) []byte {
+	if enc, ok := enc.(embeddedJSONAppender); ok {
+		return enc.AppendEmbeddedJSON(dst, j)
+	}
+	return enc.AppendBytes(dst, j)
+}
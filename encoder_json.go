@@ -7,15 +7,12 @@ import (
 	"github.com/skerkour/rz/internal/json"
 )
 
-var (
-	_ Encoder = (*json.Encoder)(nil)
+var _ Encoder = (*json.Encoder)(nil)
+var _ embeddedJSONAppender = (*json.Encoder)(nil)
 
-	enc = json.Encoder{}
-)
-
-func appendJSON(dst []byte, j []byte) []byte {
-	return append(dst, j...)
-}
+// JSONEncoder is the default, JSON, encoder of loggers. It can be embedded by custom
+// encoders.
+type JSONEncoder = json.Encoder
 
 func decodeIfBinaryToString(in []byte) string {
 	return string(in)
@@ -0,0 +1,470 @@
+package rz
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net"
+	"time"
+)
+
+// Entry is a structured view of an event, built from the fields recorded as they're
+// appended, so formatters don't have to decode the encoded event.
+type Entry struct {
+	Level LogLevel
+	// Message is empty if the event has no message.
+	Message string
+	// Timestamp is the zero time if timestamps are disabled.
+	Timestamp time.Time
+	// Caller is the file:line of the caller, if enabled.
+	Caller string
+	// Fields are the context and event fields, in the order they were added.
+	Fields []EntryField
+
+	timestampFieldName string
+	levelFieldName     string
+	messageFieldName   string
+	callerFieldName    string
+	timeFieldFormat    string
+}
+
+// EntryField is a key/value pair of an Entry.
+//
+// Value keeps the type of the field: string, bool, integers, floats,
+// time.Time, time.Duration, net.IP, net.IPNet, net.HardwareAddr, []byte, slices of
+// those, string for Hex fields, json.RawMessage for RawJSON fields or any value given
+// to Any or Map.
+// Nested objects are stored as []EntryField and arrays built element by element
+// as []interface{}.
+type EntryField struct {
+	Key   string
+	Value interface{}
+}
+
+// fieldRange is the position of a top-level entry field in the event's buffer, including
+// its separator from the previous field, if any.
+type fieldRange struct {
+	start, end int
+}
+
+// entryKind is the type of the value of an entryField.
+type entryKind uint8
+
+const (
+	kindString entryKind = iota
+	kindBool
+	kindInt
+	kindInt8
+	kindInt16
+	kindInt32
+	kindInt64
+	kindUint
+	kindUint8
+	kindUint16
+	kindUint32
+	kindUint64
+	kindFloat32
+	kindFloat64
+	kindTime
+	kindDuration
+	kindHex
+	kindRawJSON
+	kindBytes
+	kindIP
+	kindIPNet
+	kindMAC
+	kindInterface
+	// kindObject is followed by the records of its fields.
+	kindObject
+	// kindArray is followed by the records of its elements, of any kind.
+	kindArray
+	// kindSlice is followed by the records of its elements, of kind elem.
+	kindSlice
+)
+
+// entryField records a field of an event as it's appended, without converting its value
+// to an interface so the recording doesn't allocate. The value is only built, typed as
+// described by EntryField, when it's read.
+type entryField struct {
+	fieldRange // of top-level fields
+	key        string
+	kind       entryKind
+	elem       entryKind   // kind of the elements of a slice
+	num        uint64      // bools, integers, floats, durations, and the IP length of an IPNet
+	str        string      // strings
+	data       []byte      // bytes, hex, raw JSON and addresses, copied
+	t          time.Time   // times
+	value      interface{} // values given as interfaces, e.g. to Any or Map
+	size       int         // number of records nested in an object, array or slice
+}
+
+// nextEntryField returns the index of the record following the i-th one and its nested
+// records.
+func nextEntryField(records []entryField, i int) int {
+	return i + records[i].size + 1
+}
+
+// copyEntryData returns a copy of data, made at the end of arena.
+func copyEntryData(arena *[]byte, data []byte) []byte {
+	start := len(*arena)
+	*arena = append(*arena, data...)
+	return (*arena)[start:len(*arena):len(*arena)]
+}
+
+// ipNetEntryField returns the record of an IPNet, copied in arena.
+func ipNetEntryField(arena *[]byte, pfx net.IPNet) entryField {
+	start := len(*arena)
+	*arena = append(append(*arena, pfx.IP...), pfx.Mask...)
+	return entryField{kind: kindIPNet, num: uint64(len(pfx.IP)), data: (*arena)[start:len(*arena):len(*arena)]}
+}
+
+// appendEntryRecords appends records to dst, copying their data in arena.
+func appendEntryRecords(dst []entryField, arena *[]byte, records []entryField) []entryField {
+	for _, record := range records {
+		if record.data != nil {
+			record.data = copyEntryData(arena, record.data)
+		}
+		dst = append(dst, record)
+	}
+	return dst
+}
+
+// addEntryField records the top-level field f, whose value was just appended to the buffer.
+func (e *Event) addEntryField(f entryField) {
+	f.fieldRange = fieldRange{start: e.fieldStart, end: len(e.buf)}
+	e.records = append(e.records, f)
+	e.fieldStart = len(e.buf)
+}
+
+// entryValue returns the value of the field recorded by records[0], typed as described by
+// EntryField. Its data is copied, so the value remains valid once the event is recycled.
+func entryValue(records []entryField) interface{} {
+	f := &records[0]
+	switch f.kind {
+	case kindString:
+		return f.str
+	case kindBool:
+		return f.num != 0
+	case kindInt:
+		return int(f.num)
+	case kindInt8:
+		return int8(f.num)
+	case kindInt16:
+		return int16(f.num)
+	case kindInt32:
+		return int32(f.num)
+	case kindInt64:
+		return int64(f.num)
+	case kindUint:
+		return uint(f.num)
+	case kindUint8:
+		return uint8(f.num)
+	case kindUint16:
+		return uint16(f.num)
+	case kindUint32:
+		return uint32(f.num)
+	case kindUint64:
+		return f.num
+	case kindFloat32:
+		return math.Float32frombits(uint32(f.num))
+	case kindFloat64:
+		return math.Float64frombits(f.num)
+	case kindTime:
+		return f.t
+	case kindDuration:
+		return time.Duration(f.num)
+	case kindHex:
+		return hex.EncodeToString(f.data)
+	case kindRawJSON:
+		return json.RawMessage(cloneBytes(f.data))
+	case kindBytes:
+		return cloneBytes(f.data)
+	case kindIP:
+		return net.IP(cloneBytes(f.data))
+	case kindIPNet:
+		data := cloneBytes(f.data)
+		return net.IPNet{IP: data[:f.num:f.num], Mask: data[f.num:]}
+	case kindMAC:
+		return net.HardwareAddr(cloneBytes(f.data))
+	case kindObject:
+		return appendEntryFields(make([]EntryField, 0, f.size), records[1:1+f.size])
+	case kindArray:
+		values := make([]interface{}, 0, f.size)
+		for i := 1; i <= f.size; i = nextEntryField(records, i) {
+			values = append(values, entryValue(records[i:]))
+		}
+		return values
+	case kindSlice:
+		return entrySlice(f.elem, records[1:1+f.size])
+	}
+	return f.value
+}
+
+// entrySlice returns the typed slice of the elements of kind elem recorded by records.
+func entrySlice(elem entryKind, records []entryField) interface{} {
+	switch elem {
+	case kindString:
+		values := make([]string, len(records))
+		for i := range records {
+			values[i] = records[i].str
+		}
+		return values
+	case kindBool:
+		values := make([]bool, len(records))
+		for i := range records {
+			values[i] = records[i].num != 0
+		}
+		return values
+	case kindInt:
+		values := make([]int, len(records))
+		for i := range records {
+			values[i] = int(records[i].num)
+		}
+		return values
+	case kindInt8:
+		values := make([]int8, len(records))
+		for i := range records {
+			values[i] = int8(records[i].num)
+		}
+		return values
+	case kindInt16:
+		values := make([]int16, len(records))
+		for i := range records {
+			values[i] = int16(records[i].num)
+		}
+		return values
+	case kindInt32:
+		values := make([]int32, len(records))
+		for i := range records {
+			values[i] = int32(records[i].num)
+		}
+		return values
+	case kindInt64:
+		values := make([]int64, len(records))
+		for i := range records {
+			values[i] = int64(records[i].num)
+		}
+		return values
+	case kindUint:
+		values := make([]uint, len(records))
+		for i := range records {
+			values[i] = uint(records[i].num)
+		}
+		return values
+	case kindUint8:
+		values := make([]uint8, len(records))
+		for i := range records {
+			values[i] = uint8(records[i].num)
+		}
+		return values
+	case kindUint16:
+		values := make([]uint16, len(records))
+		for i := range records {
+			values[i] = uint16(records[i].num)
+		}
+		return values
+	case kindUint32:
+		values := make([]uint32, len(records))
+		for i := range records {
+			values[i] = uint32(records[i].num)
+		}
+		return values
+	case kindUint64:
+		values := make([]uint64, len(records))
+		for i := range records {
+			values[i] = records[i].num
+		}
+		return values
+	case kindFloat32:
+		values := make([]float32, len(records))
+		for i := range records {
+			values[i] = math.Float32frombits(uint32(records[i].num))
+		}
+		return values
+	case kindFloat64:
+		values := make([]float64, len(records))
+		for i := range records {
+			values[i] = math.Float64frombits(records[i].num)
+		}
+		return values
+	case kindTime:
+		values := make([]time.Time, len(records))
+		for i := range records {
+			values[i] = records[i].t
+		}
+		return values
+	case kindDuration:
+		values := make([]time.Duration, len(records))
+		for i := range records {
+			values[i] = time.Duration(records[i].num)
+		}
+		return values
+	}
+	return nil
+}
+
+func boolEntryNum(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func cloneBytes(b []byte) []byte {
+	return append(make([]byte, 0, len(b)), b...)
+}
+
+// appendEntryFields appends the top-level fields recorded by records to dst.
+func appendEntryFields(dst []EntryField, records []entryField) []EntryField {
+	for i := 0; i < len(records); i = nextEntryField(records, i) {
+		dst = append(dst, EntryField{Key: records[i].key, Value: entryValue(records[i:])})
+	}
+	return dst
+}
+
+// objectEntryFields returns the fields of obj, as they're logged by Object.
+func objectEntryFields(obj LogObjectMarshaler) []EntryField {
+	e := newEvent(nil, 0, JSONEncoder{})
+	e.withEntry = true
+	obj.MarshalRzObject(e)
+	fields := appendEntryFields(nil, e.records)
+	putEvent(e)
+	return fields
+}
+
+// appendContextData merges the encoded context data and its records into buf and
+// records, returning the updated buf and records. The ranges of contextRecords are
+// relative to data, and their data is shared.
+func appendContextData(encoder Encoder, buf []byte, records []entryField, data []byte,
+	contextRecords []entryField) ([]byte, []entryField) {
+	start := len(buf)
+	buf = encoder.AppendObjectData(buf, data)
+	shift := len(buf) - len(data)
+	first := len(records)
+	records = append(records, contextRecords...)
+	for i := first; i < len(records); i = nextEntryField(records, i) {
+		r := &records[i].fieldRange
+		if r.start == 0 {
+			// the separator added by AppendObjectData belongs to the first field
+			r.start = start
+		} else {
+			r.start += shift
+		}
+		r.end += shift
+	}
+	return buf, records
+}
+
+// lookupEntryField returns the index of the last top-level record of the field key, or -1.
+func (e *Event) lookupEntryField(key string) int {
+	found := -1
+	for i := 0; i < len(e.records); i = nextEntryField(e.records, i) {
+		if e.records[i].key == key {
+			found = i
+		}
+	}
+	return found
+}
+
+// Lookup returns the value of the top-level field key of the event, typed as described
+// by EntryField, and false if the event has no such field. If the key is set several
+// times, the last value is returned.
+// Fields are available to hooks, formatters and samplers reading them, including the
+// logger's context fields, without decoding the event: they are recorded as they're
+// appended, and their values built when they're read.
+func (e *Event) Lookup(key string) (interface{}, bool) {
+	if i := e.lookupEntryField(key); i >= 0 {
+		return entryValue(e.records[i:]), true
+	}
+	return nil, false
+}
+
+// Range calls fn for each top-level field of the event, in order, until fn returns false.
+// See Lookup for the availability of the fields.
+func (e *Event) Range(fn func(key string, value interface{}) bool) {
+	for i := 0; i < len(e.records); i = nextEntryField(e.records, i) {
+		if !fn(e.records[i].key, entryValue(e.records[i:])) {
+			return
+		}
+	}
+}
+
+// Remove removes the top-level fields key from the event, and returns false if the event
+// has no such field. See Lookup for the availability of the fields.
+func (e *Event) Remove(key string) bool {
+	removed := false
+	for i := 0; i < len(e.records); {
+		if e.records[i].key == key {
+			e.removeField(i)
+			removed = true
+		} else {
+			i = nextEntryField(e.records, i)
+		}
+	}
+	return removed
+}
+
+// Replace replaces the top-level fields key of the event by field, which is added after
+// the other fields. See Lookup for the availability of the fields.
+func (e *Event) Replace(key string, field Field) {
+	e.Remove(key)
+	field(e)
+}
+
+// removeField removes the top-level record i, and its nested records, from the records
+// and the buffer.
+func (e *Event) removeField(i int) {
+	r := e.records[i].fieldRange
+	// the key of the next field may depend on the bytes preceding it, e.g. its separator
+	var prefix []byte
+	oldPrefixLen := 0
+	next := -1
+	for j := 0; j < len(e.records); j = nextEntryField(e.records, j) {
+		if e.records[j].start == r.end && j != i {
+			next = j
+			break
+		}
+	}
+	if next >= 0 {
+		nextKey := e.records[next].key
+		oldPrefixLen = len(appendKeyAt(e.encoder, e.buf, r.end, nextKey))
+		prefix = appendKeyAt(e.encoder, e.buf, r.start, nextKey)
+	}
+
+	tail := e.buf[r.end+oldPrefixLen:]
+	shrink := r.end + oldPrefixLen - r.start - len(prefix)
+	if shrink >= 0 {
+		copy(e.buf[r.start:], prefix)
+		copy(e.buf[r.start+len(prefix):], tail)
+		e.buf = e.buf[:len(e.buf)-shrink]
+	} else {
+		buf := append(append(append([]byte(nil), e.buf[:r.start]...), prefix...), tail...)
+		e.buf = buf
+	}
+
+	for j := 0; j < len(e.records); j = nextEntryField(e.records, j) {
+		if j == next {
+			e.records[j].start = r.start
+			e.records[j].end -= shrink
+		} else if e.records[j].start >= r.end {
+			e.records[j].start -= shrink
+			e.records[j].end -= shrink
+		}
+	}
+	if e.fieldStart >= r.end {
+		e.fieldStart -= shrink
+	}
+	e.records = append(e.records[:i], e.records[nextEntryField(e.records, i):]...)
+}
+
+// appendKeyAt returns the bytes the encoder appends for key at position pos of buf.
+// Encoders only look at the last bytes of the buffer to separate keys.
+func appendKeyAt(encoder Encoder, buf []byte, pos int, key string) []byte {
+	start := pos - 2
+	if start < 0 {
+		start = 0
+	}
+	// the capacity is limited so AppendKey doesn't overwrite buf
+	window := buf[start:pos:pos]
+	return encoder.AppendKey(window, key)[len(window):]
+}
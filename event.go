@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"math"
 	"net"
 	"sync"
 	"time"
@@ -24,9 +25,11 @@ type Event struct {
 	level                LogLevel
 	done                 func(msg string)
 	stack                bool      // enable error stack trace
+	withEntry            bool      // fill in entry
 	caller               bool      // enable caller field
 	timestamp            bool      // enable timestamp
 	ch                   []LogHook // hooks from context
+	postHooks            []LogPostHook
 	timestampFieldName   string
 	levelFieldName       string
 	messageFieldName     string
@@ -38,6 +41,11 @@ type Event struct {
 	formatter            LogFormatter
 	timestampFunc        func() time.Time
 	encoder              Encoder
+	entry                Entry
+	records              []entryField // fields recorded for the entry, see Lookup
+	recordData           []byte       // data copied by records
+	fieldStart           int          // start of the next field in buf
+	stats                *SamplingStats
 }
 
 func putEvent(e *Event) {
@@ -66,11 +74,19 @@ type logArrayMarshaler interface {
 	MarshalRzArray(*array)
 }
 
-func newEvent(w LevelWriter, level LogLevel) *Event {
+func newEvent(w LevelWriter, level LogLevel, encoder Encoder) *Event {
 	e := eventPool.Get().(*Event)
 	e.buf = e.buf[:0]
 	e.ch = nil
-	e.buf = enc.AppendBeginMarker(e.buf)
+	e.postHooks = nil
+	e.encoder = encoder
+	e.withEntry = false
+	e.stats = nil
+	e.entry = Entry{Fields: e.entry.Fields[:0]}
+	e.records = e.records[:0]
+	e.recordData = e.recordData[:0]
+	e.buf = e.encoder.AppendBeginMarker(e.buf)
+	e.fieldStart = len(e.buf)
 	e.w = w
 	e.level = level
 	return e
@@ -90,7 +106,8 @@ func (e *Event) Append(fields ...Field) {
 }
 
 // Fields returns the fields from the event.
-// Note that this call is very expensive and should be used sparingly.
+// Note that this call is very expensive and should be used sparingly: hooks should
+// prefer Lookup and Range.
 func (e *Event) Fields() (map[string]interface{}, error) {
 	var fields map[string]interface{}
 
@@ -118,20 +135,21 @@ func (e *Event) fields(fields map[string]interface{}) {
 // Dict adds the field key with a dict to the event context.
 // Use rz.Dict() to create the dictionary.
 func (e *Event) dict(key string, dict *Event) {
-	dict.buf = enc.AppendEndMarker(dict.buf)
-	e.buf = append(enc.AppendKey(e.buf, key), dict.buf...)
+	dict.buf = e.encoder.AppendEndMarker(dict.buf)
+	e.buf = append(e.encoder.AppendKey(e.buf, key), dict.buf...)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindObject, size: len(dict.records)})
+		// dict's data is reused once it's recycled
+		e.records = appendEntryRecords(e.records, &e.recordData, dict.records)
+	}
 	putEvent(dict)
 }
 
-func newDict() *Event {
-	return newEvent(nil, 0)
-}
-
 // Array adds the field key with an array to the event context.
 // Use Event.Arr() to create the array or pass a type that
 // implement the LogArrayMarshaler interface.
 func (e *Event) array(key string, arr logArrayMarshaler) {
-	e.buf = enc.AppendKey(e.buf, key)
+	e.buf = e.encoder.AppendKey(e.buf, key)
 	var a *array
 	if aa, ok := arr.(*array); ok {
 		a = aa
@@ -139,19 +157,39 @@ func (e *Event) array(key string, arr logArrayMarshaler) {
 		a = e.arr()
 		arr.MarshalRzArray(a)
 	}
+	// a is recycled once written
+	header := len(e.records)
+	if e.withEntry {
+		e.records = append(e.records, entryField{key: key, kind: kindArray, size: len(a.records)})
+		e.records = appendEntryRecords(e.records, &e.recordData, a.records)
+	}
 	e.buf = a.write(e.buf)
+	if e.withEntry {
+		e.records[header].fieldRange = fieldRange{start: e.fieldStart, end: len(e.buf)}
+		e.fieldStart = len(e.buf)
+	}
 }
 
 func (e *Event) appendObject(obj LogObjectMarshaler) {
-	e.buf = enc.AppendBeginMarker(e.buf)
+	e.buf = e.encoder.AppendBeginMarker(e.buf)
 	obj.MarshalRzObject(e)
-	e.buf = enc.AppendEndMarker(e.buf)
+	e.buf = e.encoder.AppendEndMarker(e.buf)
 }
 
 // Object marshals an object that implement the LogObjectMarshaler interface.
 func (e *Event) object(key string, obj LogObjectMarshaler) {
-	e.buf = enc.AppendKey(e.buf, key)
+	e.buf = e.encoder.AppendKey(e.buf, key)
+	if !e.withEntry {
+		e.appendObject(obj)
+		return
+	}
+	// the object's fields are recorded after its own record
+	header, start := len(e.records), e.fieldStart
+	e.records = append(e.records, entryField{key: key, kind: kindObject})
 	e.appendObject(obj)
+	e.records[header].size = len(e.records) - header - 1
+	e.records[header].fieldRange = fieldRange{start: start, end: len(e.buf)}
+	e.fieldStart = len(e.buf)
 }
 
 // embedObject marshals an object that implement the LogObjectMarshaler interface.
@@ -161,12 +199,21 @@ func (e *Event) embedObject(obj LogObjectMarshaler) {
 
 // String adds the field key with val as a string to the *Event context.
 func (e *Event) string(key, val string) {
-	e.buf = enc.AppendString(enc.AppendKey(e.buf, key), val)
+	e.buf = e.encoder.AppendString(e.encoder.AppendKey(e.buf, key), val)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindString, str: val})
+	}
 }
 
 // Strings adds the field key with vals as a []string to the *Event context.
 func (e *Event) strings(key string, vals []string) {
-	e.buf = enc.AppendStrings(enc.AppendKey(e.buf, key), vals)
+	e.buf = e.encoder.AppendStrings(e.encoder.AppendKey(e.buf, key), vals)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindString, size: len(vals)})
+		for _, x := range vals {
+			e.records = append(e.records, entryField{kind: kindString, str: x})
+		}
+	}
 }
 
 // Bytes adds the field key with val as a string to the *Event context.
@@ -174,12 +221,18 @@ func (e *Event) strings(key string, vals []string) {
 // Runes outside of normal ASCII ranges will be hex-encoded in the resulting
 // JSON.
 func (e *Event) bytes(key string, val []byte) {
-	e.buf = enc.AppendBytes(enc.AppendKey(e.buf, key), val)
+	e.buf = e.encoder.AppendBytes(e.encoder.AppendKey(e.buf, key), val)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindBytes, data: copyEntryData(&e.recordData, val)})
+	}
 }
 
 // Hex adds the field key with val as a hex string to the *Event context.
 func (e *Event) hex(key string, val []byte) {
-	e.buf = enc.AppendHex(enc.AppendKey(e.buf, key), val)
+	e.buf = e.encoder.AppendHex(e.encoder.AppendKey(e.buf, key), val)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindHex, data: copyEntryData(&e.recordData, val)})
+	}
 }
 
 // RawJSON adds already encoded JSON to the log line under key.
@@ -187,7 +240,10 @@ func (e *Event) hex(key string, val []byte) {
 // No sanity check is performed on b; it must not contain carriage returns and
 // be valid JSON.
 func (e *Event) rawJSON(key string, b []byte) {
-	e.buf = appendJSON(enc.AppendKey(e.buf, key), b)
+	e.buf = appendEmbeddedJSON(e.encoder, e.encoder.AppendKey(e.buf, key), b)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindRawJSON, data: copyEntryData(&e.recordData, b)})
+	}
 }
 
 // Error adds the field key with serialized err to the *Event context.
@@ -259,139 +315,256 @@ func (e *Event) enableStack(enable bool) {
 
 // Bool adds the field key with val as a bool to the *Event context.
 func (e *Event) bool(key string, b bool) {
-	e.buf = enc.AppendBool(enc.AppendKey(e.buf, key), b)
+	e.buf = e.encoder.AppendBool(e.encoder.AppendKey(e.buf, key), b)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindBool, num: boolEntryNum(b)})
+	}
 }
 
 // Bools adds the field key with val as a []bool to the *Event context.
 func (e *Event) bools(key string, b []bool) {
-	e.buf = enc.AppendBools(enc.AppendKey(e.buf, key), b)
+	e.buf = e.encoder.AppendBools(e.encoder.AppendKey(e.buf, key), b)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindBool, size: len(b)})
+		for _, x := range b {
+			e.records = append(e.records, entryField{kind: kindBool, num: boolEntryNum(x)})
+		}
+	}
 }
 
 // Int adds the field key with i as a int to the *Event context.
 func (e *Event) int(key string, i int) {
-	e.buf = enc.AppendInt(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendInt(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindInt, num: uint64(i)})
+	}
 }
 
 // Ints adds the field key with i as a []int to the *Event context.
 func (e *Event) ints(key string, i []int) {
-	e.buf = enc.AppendInts(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendInts(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindInt, size: len(i)})
+		for _, x := range i {
+			e.records = append(e.records, entryField{kind: kindInt, num: uint64(x)})
+		}
+	}
 }
 
 // Int8 adds the field key with i as a int8 to the *Event context.
 func (e *Event) int8(key string, i int8) {
-	e.buf = enc.AppendInt8(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendInt8(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindInt8, num: uint64(i)})
+	}
 }
 
 // Ints8 adds the field key with i as a []int8 to the *Event context.
 func (e *Event) ints8(key string, i []int8) {
-	e.buf = enc.AppendInts8(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendInts8(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindInt8, size: len(i)})
+		for _, x := range i {
+			e.records = append(e.records, entryField{kind: kindInt8, num: uint64(x)})
+		}
+	}
 }
 
 // Int16 adds the field key with i as a int16 to the *Event context.
 func (e *Event) int16(key string, i int16) {
-	e.buf = enc.AppendInt16(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendInt16(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindInt16, num: uint64(i)})
+	}
 }
 
 // Ints16 adds the field key with i as a []int16 to the *Event context.
 func (e *Event) ints16(key string, i []int16) {
-	e.buf = enc.AppendInts16(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendInts16(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindInt16, size: len(i)})
+		for _, x := range i {
+			e.records = append(e.records, entryField{kind: kindInt16, num: uint64(x)})
+		}
+	}
 }
 
 // Int32 adds the field key with i as a int32 to the *Event context.
 func (e *Event) int32(key string, i int32) {
-	e.buf = enc.AppendInt32(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendInt32(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindInt32, num: uint64(i)})
+	}
 }
 
 // Ints32 adds the field key with i as a []int32 to the *Event context.
 func (e *Event) ints32(key string, i []int32) {
-	e.buf = enc.AppendInts32(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendInts32(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindInt32, size: len(i)})
+		for _, x := range i {
+			e.records = append(e.records, entryField{kind: kindInt32, num: uint64(x)})
+		}
+	}
 }
 
 // Int64 adds the field key with i as a int64 to the *Event context.
 func (e *Event) int64(key string, i int64) {
-	e.buf = enc.AppendInt64(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendInt64(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindInt64, num: uint64(i)})
+	}
 }
 
 // Ints64 adds the field key with i as a []int64 to the *Event context.
 func (e *Event) ints64(key string, i []int64) {
-	e.buf = enc.AppendInts64(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendInts64(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindInt64, size: len(i)})
+		for _, x := range i {
+			e.records = append(e.records, entryField{kind: kindInt64, num: uint64(x)})
+		}
+	}
 }
 
 // Uint adds the field key with i as a uint to the *Event context.
 func (e *Event) uint(key string, i uint) {
-	e.buf = enc.AppendUint(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendUint(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindUint, num: uint64(i)})
+	}
 }
 
 // Uints adds the field key with i as a []int to the *Event context.
 func (e *Event) uints(key string, i []uint) {
-	e.buf = enc.AppendUints(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendUints(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindUint, size: len(i)})
+		for _, x := range i {
+			e.records = append(e.records, entryField{kind: kindUint, num: uint64(x)})
+		}
+	}
 }
 
 // Uint8 adds the field key with i as a uint8 to the *Event context.
 func (e *Event) uint8(key string, i uint8) {
-	e.buf = enc.AppendUint8(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendUint8(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindUint8, num: uint64(i)})
+	}
 }
 
 // Uints8 adds the field key with i as a []int8 to the *Event context.
 func (e *Event) uints8(key string, i []uint8) {
-	e.buf = enc.AppendUints8(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendUints8(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindUint8, size: len(i)})
+		for _, x := range i {
+			e.records = append(e.records, entryField{kind: kindUint8, num: uint64(x)})
+		}
+	}
 }
 
 // Uint16 adds the field key with i as a uint16 to the *Event context.
 func (e *Event) uint16(key string, i uint16) {
-	e.buf = enc.AppendUint16(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendUint16(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindUint16, num: uint64(i)})
+	}
 }
 
 // Uints16 adds the field key with i as a []int16 to the *Event context.
 func (e *Event) uints16(key string, i []uint16) {
-	e.buf = enc.AppendUints16(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendUints16(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindUint16, size: len(i)})
+		for _, x := range i {
+			e.records = append(e.records, entryField{kind: kindUint16, num: uint64(x)})
+		}
+	}
 }
 
 // Uint32 adds the field key with i as a uint32 to the *Event context.
 func (e *Event) uint32(key string, i uint32) {
-	e.buf = enc.AppendUint32(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendUint32(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindUint32, num: uint64(i)})
+	}
 }
 
 // Uints32 adds the field key with i as a []int32 to the *Event context.
 func (e *Event) uints32(key string, i []uint32) {
-	e.buf = enc.AppendUints32(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendUints32(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindUint32, size: len(i)})
+		for _, x := range i {
+			e.records = append(e.records, entryField{kind: kindUint32, num: uint64(x)})
+		}
+	}
 }
 
 // Uint64 adds the field key with i as a uint64 to the *Event context.
 func (e *Event) uint64(key string, i uint64) {
-	e.buf = enc.AppendUint64(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendUint64(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindUint64, num: i})
+	}
 }
 
 // Uints64 adds the field key with i as a []int64 to the *Event context.
 func (e *Event) uints64(key string, i []uint64) {
-	e.buf = enc.AppendUints64(enc.AppendKey(e.buf, key), i)
+	e.buf = e.encoder.AppendUints64(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindUint64, size: len(i)})
+		for _, x := range i {
+			e.records = append(e.records, entryField{kind: kindUint64, num: x})
+		}
+	}
 }
 
 // Float32 adds the field key with f as a float32 to the *Event context.
 func (e *Event) float32(key string, f float32) {
-	e.buf = enc.AppendFloat32(enc.AppendKey(e.buf, key), f)
+	e.buf = e.encoder.AppendFloat32(e.encoder.AppendKey(e.buf, key), f)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindFloat32, num: uint64(math.Float32bits(f))})
+	}
 }
 
 // Floats32 adds the field key with f as a []float32 to the *Event context.
 func (e *Event) floats32(key string, f []float32) {
-	e.buf = enc.AppendFloats32(enc.AppendKey(e.buf, key), f)
+	e.buf = e.encoder.AppendFloats32(e.encoder.AppendKey(e.buf, key), f)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindFloat32, size: len(f)})
+		for _, x := range f {
+			e.records = append(e.records, entryField{kind: kindFloat32, num: uint64(math.Float32bits(x))})
+		}
+	}
 }
 
 // Float64 adds the field key with f as a float64 to the *Event context.
 func (e *Event) float64(key string, f float64) {
-	e.buf = enc.AppendFloat64(enc.AppendKey(e.buf, key), f)
+	e.buf = e.encoder.AppendFloat64(e.encoder.AppendKey(e.buf, key), f)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindFloat64, num: math.Float64bits(f)})
+	}
 }
 
 // Floats64 adds the field key with f as a []float64 to the *Event context.
 func (e *Event) floats64(key string, f []float64) {
-	e.buf = enc.AppendFloats64(enc.AppendKey(e.buf, key), f)
+	e.buf = e.encoder.AppendFloats64(e.encoder.AppendKey(e.buf, key), f)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindFloat64, size: len(f)})
+		for _, x := range f {
+			e.records = append(e.records, entryField{kind: kindFloat64, num: math.Float64bits(x)})
+		}
+	}
 }
 
 // Timestamp adds the current local time as UNIX timestamp to the *Event context with the
 // logger.TimestampFieldName key.
 // func (e *Event) Timestamp() {
 // 	e.timestamp = false
-// 	e.buf = enc.AppendTime(enc.AppendKey(e.buf, e.timestampFieldName), e.timestampFunc(), e.timeFieldFormat)
+// 	e.buf = e.encoder.AppendTime(e.encoder.AppendKey(e.buf, e.timestampFieldName), e.timestampFunc(), e.timeFieldFormat)
 // 	return e
 // }
 func (e *Event) enableTimestamp(enable bool) {
@@ -400,34 +573,56 @@ func (e *Event) enableTimestamp(enable bool) {
 
 // Time adds the field key with t formated as string using rz.TimeFieldFormat.
 func (e *Event) time(key string, t time.Time) {
-	e.buf = enc.AppendTime(enc.AppendKey(e.buf, key), t, e.timeFieldFormat)
+	e.buf = e.encoder.AppendTime(e.encoder.AppendKey(e.buf, key), t, e.timeFieldFormat)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindTime, t: t})
+	}
 }
 
 // Times adds the field key with t formated as string using rz.TimeFieldFormat.
 func (e *Event) times(key string, t []time.Time) {
-	e.buf = enc.AppendTimes(enc.AppendKey(e.buf, key), t, e.timeFieldFormat)
+	e.buf = e.encoder.AppendTimes(e.encoder.AppendKey(e.buf, key), t, e.timeFieldFormat)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindTime, size: len(t)})
+		for _, x := range t {
+			e.records = append(e.records, entryField{kind: kindTime, t: x})
+		}
+	}
 }
 
 // Duration adds the field key with duration d stored as rz.DurationFieldUnit.
 // If rz.DurationFieldInteger is true, durations are rendered as integer
 // instead of float.
 func (e *Event) duration(key string, d time.Duration) {
-	e.buf = enc.AppendDuration(enc.AppendKey(e.buf, key), d, DurationFieldUnit, DurationFieldInteger)
+	e.buf = e.encoder.AppendDuration(e.encoder.AppendKey(e.buf, key), d, DurationFieldUnit, DurationFieldInteger)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindDuration, num: uint64(d)})
+	}
 }
 
 // Durations adds the field key with duration d stored as rz.DurationFieldUnit.
 // If rz.DurationFieldInteger is true, durations are rendered as integer
 // instead of float.
 func (e *Event) durations(key string, d []time.Duration) {
-	e.buf = enc.AppendDurations(enc.AppendKey(e.buf, key), d, DurationFieldUnit, DurationFieldInteger)
+	e.buf = e.encoder.AppendDurations(e.encoder.AppendKey(e.buf, key), d, DurationFieldUnit, DurationFieldInteger)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindSlice, elem: kindDuration, size: len(d)})
+		for _, x := range d {
+			e.records = append(e.records, entryField{kind: kindDuration, num: uint64(x)})
+		}
+	}
 }
 
 // Interface adds the field key with i marshaled using reflection.
 func (e *Event) iinterface(key string, i interface{}) {
 	if obj, ok := i.(LogObjectMarshaler); ok {
 		e.object(key, obj)
+		return
+	}
+	e.buf = e.encoder.AppendInterface(e.encoder.AppendKey(e.buf, key), i)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindInterface, value: i})
 	}
-	e.buf = enc.AppendInterface(enc.AppendKey(e.buf, key), i)
 }
 
 // enableCaller adds the file:line of the caller with the rz.CallerFieldName key.
@@ -437,15 +632,26 @@ func (e *Event) enableCaller(enable bool) {
 
 // ip adds IPv4 or IPv6 Address to the event
 func (e *Event) ip(key string, ip net.IP) {
-	e.buf = enc.AppendIPAddr(enc.AppendKey(e.buf, key), ip)
+	e.buf = e.encoder.AppendIPAddr(e.encoder.AppendKey(e.buf, key), ip)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindIP, data: copyEntryData(&e.recordData, ip)})
+	}
 }
 
 // ipNet adds IPv4 or IPv6 Prefix (address and mask) to the event
 func (e *Event) ipNet(key string, pfx net.IPNet) {
-	e.buf = enc.AppendIPPrefix(enc.AppendKey(e.buf, key), pfx)
+	e.buf = e.encoder.AppendIPPrefix(e.encoder.AppendKey(e.buf, key), pfx)
+	if e.withEntry {
+		f := ipNetEntryField(&e.recordData, pfx)
+		f.key = key
+		e.addEntryField(f)
+	}
 }
 
 // hardwareAddr adds MAC address to the event
 func (e *Event) hardwareAddr(key string, ha net.HardwareAddr) {
-	e.buf = enc.AppendMACAddr(enc.AppendKey(e.buf, key), ha)
+	e.buf = e.encoder.AppendMACAddr(e.encoder.AppendKey(e.buf, key), ha)
+	if e.withEntry {
+		e.addEntryField(entryField{key: key, kind: kindMAC, data: copyEntryData(&e.recordData, ha)})
+	}
 }
@@ -3,6 +3,8 @@ package rz
 import (
 	"reflect"
 	"testing"
+
+	"github.com/skerkour/rz/internal/json"
 )
 
 func TestEvent_Fields(t *testing.T) {
@@ -10,7 +12,7 @@ func TestEvent_Fields(t *testing.T) {
 		"hostname": "localhost",
 		"latency":  3000.0,
 	}
-	event := newEvent(nil, DebugLevel)
+	event := newEvent(nil, DebugLevel, json.Encoder{})
 
 	for key, value := range fields {
 		event.Append(Any(key, value))
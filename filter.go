@@ -0,0 +1,98 @@
+package rz
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// LevelFilterEnv is the environment variable read by EnvLevelFilter.
+const LevelFilterEnv = "RZ_LOG"
+
+// LevelFilter resolves the level of named loggers from directives such as
+// "info,db=debug,db.pool=warn,http=error": a directive without name sets the default
+// level, and the level of a logger is the one of the longest matching name. A name
+// matches the logger's name and its children: "db" matches "db" and "db.pool" but not
+// "dbx". The "off" level disables the matching loggers.
+// Loggers without a matching directive nor default level use their own level.
+//
+// A LevelFilter is safe for concurrent use, and can be reloaded at runtime.
+type LevelFilter struct {
+	directives atomic.Value // *levelDirectives
+}
+
+type levelDirectives struct {
+	defaultLevel    LogLevel
+	hasDefaultLevel bool
+	levels          map[string]LogLevel
+}
+
+// NewLevelFilter creates a LevelFilter from directives.
+func NewLevelFilter(directives string) (*LevelFilter, error) {
+	filter := &LevelFilter{}
+	if err := filter.Reload(directives); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// EnvLevelFilter creates a LevelFilter from the directives of the RZ_LOG environment
+// variable.
+func EnvLevelFilter() (*LevelFilter, error) {
+	return NewLevelFilter(os.Getenv(LevelFilterEnv))
+}
+
+// Reload replaces the filter's directives. The directives are left unchanged if they
+// can't be parsed.
+func (f *LevelFilter) Reload(directives string) error {
+	parsed, err := parseLevelDirectives(directives)
+	if err != nil {
+		return err
+	}
+	f.directives.Store(parsed)
+	return nil
+}
+
+// Level returns the level of the logger named name, and false if no directive applies.
+func (f *LevelFilter) Level(name string) (LogLevel, bool) {
+	directives := f.directives.Load().(*levelDirectives)
+	if len(directives.levels) > 0 {
+		for prefix := name; prefix != ""; {
+			if level, ok := directives.levels[prefix]; ok {
+				return level, true
+			}
+			i := strings.LastIndexByte(prefix, '.')
+			if i < 0 {
+				break
+			}
+			prefix = prefix[:i]
+		}
+	}
+	return directives.defaultLevel, directives.hasDefaultLevel
+}
+
+func parseLevelDirectives(directives string) (*levelDirectives, error) {
+	parsed := &levelDirectives{levels: map[string]LogLevel{}}
+	for _, directive := range strings.Split(directives, ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "" {
+			continue
+		}
+		name, levelStr := "", directive
+		if i := strings.IndexByte(directive, '='); i >= 0 {
+			name, levelStr = strings.TrimSpace(directive[:i]), strings.TrimSpace(directive[i+1:])
+		}
+		level, err := ParseLevel(levelStr)
+		if err != nil {
+			return nil, fmt.Errorf("rz: invalid level directive %q: %w", directive, err)
+		}
+		if name == "" {
+			parsed.defaultLevel = level
+			parsed.hasDefaultLevel = true
+		} else {
+			parsed.levels[name] = level
+		}
+	}
+	return parsed, nil
+}
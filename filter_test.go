@@ -0,0 +1,74 @@
+package rz
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLevelFilter(t *testing.T) {
+	filter, err := NewLevelFilter(" info, db=debug,db.pool=warn ,http=error,noisy=off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		level LogLevel
+	}{
+		{"", InfoLevel},
+		{"app", InfoLevel},
+		{"db", DebugLevel},
+		{"dbx", InfoLevel},
+		{"db.query", DebugLevel},
+		{"db.pool", WarnLevel},
+		{"db.pool.conn", WarnLevel},
+		{"http", ErrorLevel},
+		{"noisy.child", Disabled},
+	}
+	for _, tt := range tests {
+		if got, ok := filter.Level(tt.name); !ok || got != tt.level {
+			t.Errorf("Level(%q) = %v, %v, want %v, true", tt.name, got, ok, tt.level)
+		}
+	}
+
+	if err := filter.Reload("db=error"); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := filter.Level("db.pool"); !ok || got != ErrorLevel {
+		t.Errorf("after Reload, Level(\"db.pool\") = %v, %v, want %v, true", got, ok, ErrorLevel)
+	}
+	if _, ok := filter.Level("app"); ok {
+		t.Errorf("after Reload, Level(\"app\") should not be resolved")
+	}
+
+	if err := filter.Reload("db=loud"); err == nil {
+		t.Error("Reload() with an invalid level should fail")
+	}
+	if got, _ := filter.Level("db"); got != ErrorLevel {
+		t.Errorf("a failed Reload() should keep the directives, Level(\"db\") = %v", got)
+	}
+}
+
+func TestNamedLoggerFilter(t *testing.T) {
+	out := &bytes.Buffer{}
+	filter, err := NewLevelFilter("db=debug,db.pool=warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := New(Writer(out), Level(InfoLevel), Filter(filter), Fields(Timestamp(false)))
+	db := log.Named("db")
+	pool := db.Named("pool")
+
+	log.Debug("hidden")
+	db.Debug("visible")
+	pool.Info("hidden")
+	pool.Warn("visible")
+
+	want := `{"level":"debug","component":"db","message":"visible"}` + "\n" +
+		`{"level":"warning","component":"db.pool","message":"visible"}` + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+	if got := pool.Name(); got != "db.pool" {
+		t.Errorf("Name() = %q, want %q", got, "db.pool")
+	}
+}
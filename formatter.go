@@ -1,4 +1,176 @@
 package rz
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
 // LogFormatter can be used to log to another format than JSON
 type LogFormatter func(ev *Event) ([]byte, error)
+
+// LogEntryFormatter can be used to log to another format than JSON, from a structured
+// view of the event.
+type LogEntryFormatter func(entry *Entry) ([]byte, error)
+
+// EntryFormatter adapts a LogEntryFormatter to be used as a logger's LogFormatter.
+func EntryFormatter(formatter LogEntryFormatter) LogFormatter {
+	return func(ev *Event) ([]byte, error) {
+		ev.entry.Fields = appendEntryFields(ev.entry.Fields[:0], ev.records)
+		return formatter(&ev.entry)
+	}
+}
+
+// walkEntryFields calls fn for each field, flattening nested objects with dotted keys.
+func walkEntryFields(prefix string, fields []EntryField, fn func(key string, value interface{})) {
+	for _, field := range fields {
+		key := field.Key
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := field.Value.([]EntryField); ok && len(nested) > 0 {
+			walkEntryFields(key, nested, fn)
+			continue
+		}
+		fn(key, field.Value)
+	}
+}
+
+// fieldKey returns the key of a field, quoted if needed.
+func fieldKey(key string) string {
+	if needsQuote(key) {
+		return strconv.Quote(key)
+	}
+	return key
+}
+
+// writeFieldValue writes a field's value as plain text, quoting strings if needed. Times
+// are formatted with timeFormat, as the encoder does. Values without a text representation,
+// such as nested objects and arrays, are written as JSON, quoted if quoteJSON is true.
+func writeFieldValue(buf *bytes.Buffer, value interface{}, quoteJSON bool, timeFormat string) {
+	switch v := value.(type) {
+	case string:
+		writeFieldString(buf, v)
+	case []byte:
+		writeFieldString(buf, string(v))
+	case json.RawMessage:
+		writeFieldJSON(buf, v, quoteJSON)
+	case time.Time:
+		writeFieldString(buf, formatTime(v, timeFormat))
+	case time.Duration:
+		buf.WriteString(v.String())
+	case net.IPNet:
+		buf.WriteString(v.String())
+	case error:
+		writeFieldString(buf, v.Error())
+	case fmt.Stringer:
+		writeFieldString(buf, v.String())
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case int:
+		buf.WriteString(strconv.Itoa(v))
+	case int64:
+		buf.WriteString(strconv.FormatInt(v, 10))
+	case uint64:
+		buf.WriteString(strconv.FormatUint(v, 10))
+	case float64:
+		buf.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		b, err := json.Marshal(entryValueToJSON(value, timeFormat))
+		if err != nil {
+			fmt.Fprintf(buf, "[error: %v]", err)
+		} else {
+			writeFieldJSON(buf, b, quoteJSON)
+		}
+	}
+}
+
+func writeFieldJSON(buf *bytes.Buffer, b []byte, quote bool) {
+	if quote {
+		writeFieldString(buf, string(b))
+	} else {
+		buf.Write(b)
+	}
+}
+
+func writeFieldString(buf *bytes.Buffer, s string) {
+	if len(s) == 0 {
+		buf.WriteString(`""`)
+	} else if needsQuote(s) {
+		buf.WriteString(strconv.Quote(s))
+	} else {
+		buf.WriteString(s)
+	}
+}
+
+// entryValueText returns the text of a field value, typed as described by EntryField or
+// decoded from JSON, for writers needing plain text values. Times are formatted with
+// timeFormat, values without a text representation are encoded in JSON.
+func entryValueText(value interface{}, timeFormat string) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return formatTime(v, timeFormat)
+	case json.Number, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, time.Duration, net.IP, net.HardwareAddr, error:
+		return fmt.Sprint(v)
+	case net.IPNet:
+		return v.String()
+	}
+	b, _ := json.Marshal(entryValueToJSON(value, timeFormat))
+	return string(b)
+}
+
+// formatTime formats t as the encoders do: with format, or as a UNIX timestamp if format
+// is empty.
+func formatTime(t time.Time, format string) string {
+	if format == "" {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return t.Format(format)
+}
+
+// entryValueToJSON converts nested entry fields and arrays so they can be marshaled
+// with encoding/json, formatting times with timeFormat.
+func entryValueToJSON(value interface{}, timeFormat string) interface{} {
+	switch v := value.(type) {
+	case []EntryField:
+		m := make(map[string]interface{}, len(v))
+		for _, field := range v {
+			m[field.Key] = entryValueToJSON(field.Value, timeFormat)
+		}
+		return m
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = entryValueToJSON(v[i], timeFormat)
+		}
+		return values
+	case time.Time:
+		if timeFormat == "" {
+			return v.Unix()
+		}
+		return v.Format(timeFormat)
+	case []time.Time:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = entryValueToJSON(v[i], timeFormat)
+		}
+		return values
+	case time.Duration:
+		return v.String()
+	case net.IPNet:
+		return v.String()
+	case error:
+		return v.Error()
+	case []byte:
+		return string(v)
+	}
+	return value
+}
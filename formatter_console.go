@@ -2,10 +2,7 @@ package rz
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
-	"sort"
-	"strconv"
 	"strings"
 )
 
@@ -24,100 +21,70 @@ const (
 
 // FormatterConsole prettify output for human cosumption
 func FormatterConsole() LogFormatter {
-	return func(ev *Event) ([]byte, error) {
-		var event map[string]interface{}
+	return EntryFormatter(func(entry *Entry) ([]byte, error) {
 		var ret = new(bytes.Buffer)
 
-		d := json.NewDecoder(bytes.NewReader(ev.buf))
-		d.UseNumber()
-		err := d.Decode(&event)
-		if err != nil {
-			return ret.Bytes(), err
-		}
-
 		lvlColor := cReset
 		level := "????"
-		if l, ok := event[DefaultLevelFieldName].(string); ok {
-			lvlColor = levelColor(l)
-			level = strings.ToUpper(l)[0:4]
-		}
-
-		message := ""
-		if m, ok := event[DefaultMessageFieldName].(string); ok {
-			message = m
+		if l := entry.Level.String(); l != "" {
+			lvlColor = levelColor(entry.Level)
+			level = strings.ToUpper(l)
+			if len(level) > 4 {
+				level = level[0:4]
+			}
 		}
 
 		timestamp := ""
-		if t, ok := event[DefaultTimestampFieldName].(string); ok {
-			timestamp = t
+		if !entry.Timestamp.IsZero() {
+			timestamp = formatTime(entry.Timestamp, entry.timeFieldFormat)
 		}
 
 		ret.WriteString(fmt.Sprintf("%-20s |%-4s|",
 			timestamp,
 			colorize(level, lvlColor),
 		))
-		if message != "" {
-			ret.WriteString(" " + message)
+		if entry.Message != "" {
+			ret.WriteString(" " + entry.Message)
 		}
 
-		fields := make([]string, 0, len(event))
-		for field := range event {
-			switch field {
-			case DefaultTimestampFieldName, DefaultMessageFieldName, DefaultLevelFieldName:
-				continue
-			}
-
-			fields = append(fields, field)
+		writeField := func(key string, value interface{}) {
+			fmt.Fprintf(ret, " %s=", colorize(fieldKey(key), lvlColor))
+			writeFieldValue(ret, value, false, entry.timeFieldFormat)
 		}
-
-		sort.Strings(fields)
-		for _, field := range fields {
-			if needsQuote(field) {
-				field = strconv.Quote(field)
-			}
-			fmt.Fprintf(ret, " %s=", colorize(field, lvlColor))
-
-			switch value := event[field].(type) {
-			case string:
-				if len(value) == 0 {
-					ret.WriteString("\"\"")
-				} else if needsQuote(value) {
-					ret.WriteString(strconv.Quote(value))
-				} else {
-					ret.WriteString(value)
-				}
-			default:
-				b, err := json.Marshal(value)
-				if err != nil {
-					return ret.Bytes(), err
-				}
-				fmt.Fprint(ret, string(b))
-			}
+		for _, field := range entry.Fields {
+			writeField(field.Key, field.Value)
+		}
+		if entry.Caller != "" {
+			writeField(entry.callerFieldName, entry.Caller)
 		}
 
 		ret.WriteByte('\n')
 
 		return ret.Bytes(), nil
-	}
+	})
 }
 
 func colorize(s interface{}, color int) string {
 	return fmt.Sprintf("\x1b[%dm%v\x1b[0m", color, s)
 }
 
-func levelColor(level string) int {
+func levelColor(level LogLevel) int {
 	switch level {
-	case "debug":
+	case TraceLevel:
+		return cBlue
+	case DebugLevel:
 		return cMagenta
-	case "info":
+	case InfoLevel:
 		return cCyan
-	case "warning":
+	case WarnLevel:
 		return cYellow
-	case "error", "fatal", "panic":
+	case ErrorLevel, FatalLevel, PanicLevel:
 		return cRed
-	default:
-		return cReset
 	}
+	if custom, ok := lookupCustomLevel(level); ok {
+		return custom.Color
+	}
+	return cReset
 }
 
 func needsQuote(s string) bool {
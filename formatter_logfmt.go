@@ -2,62 +2,40 @@ package rz
 
 import (
 	"bytes"
-	"encoding/json"
-	"fmt"
-	"sort"
-	"strconv"
-	"time"
 )
 
 // FormatterLogfmt prettify output for human consumption, using the logfmt format.
+// The logfmt.Encoder writes logfmt natively, without building an Entry, and should be
+// preferred.
 func FormatterLogfmt() LogFormatter {
-	return func(ev *Event) ([]byte, error) {
-		var event map[string]interface{}
+	return EntryFormatter(func(entry *Entry) ([]byte, error) {
 		var ret = new(bytes.Buffer)
 
-		d := json.NewDecoder(bytes.NewReader(ev.buf))
-		d.UseNumber()
-		err := d.Decode(&event)
-		if err != nil {
-			return ret.Bytes(), err
+		writeField := func(key string, value interface{}) {
+			if ret.Len() > 0 {
+				ret.WriteByte(' ')
+			}
+			ret.WriteString(fieldKey(key))
+			ret.WriteByte('=')
+			writeFieldValue(ret, value, true, entry.timeFieldFormat)
 		}
 
-		fields := make([]string, 0, len(event))
-		for field := range event {
-			fields = append(fields, field)
+		if !entry.Timestamp.IsZero() {
+			writeField(entry.timestampFieldName, entry.Timestamp)
 		}
-
-		sort.Strings(fields)
-		for _, field := range fields {
-			if needsQuote(field) {
-				field = strconv.Quote(field)
-			}
-			fmt.Fprintf(ret, " %s=", field)
-
-			switch value := event[field].(type) {
-			case string:
-				if len(value) == 0 {
-					ret.WriteString("\"\"")
-				} else if needsQuote(value) {
-					ret.WriteString(strconv.Quote(value))
-				} else {
-					ret.WriteString(value)
-				}
-			case time.Time:
-				ret.WriteString(value.Format(time.RFC3339))
-			default:
-				b, err := json.Marshal(value)
-				if err != nil {
-					fmt.Fprintf(ret, "[error: %v]", err)
-				} else {
-					fmt.Fprint(ret, strconv.Quote(string(b)))
-				}
-			}
-
+		if level := entry.Level.String(); level != "" {
+			writeField(entry.levelFieldName, level)
+		}
+		if entry.Message != "" {
+			writeField(entry.messageFieldName, entry.Message)
+		}
+		walkEntryFields("", entry.Fields, writeField)
+		if entry.Caller != "" {
+			writeField(entry.callerFieldName, entry.Caller)
 		}
 
 		ret.WriteByte('\n')
 
 		return ret.Bytes(), nil
-	}
+	})
 }
@@ -15,14 +15,95 @@ func (h HookFunc) Run(e *Event, level LogLevel, message string) {
 	h(e, level, message)
 }
 
-// LevelHook applies a different hook for each level.
+// LogPostHook defines an interface to a hook run after the event is written.
+type LogPostHook interface {
+	// RunPost runs the hook with the level of the event, its final bytes, as given to the
+	// writer, and the write error. p must not be retained after RunPost returns.
+	RunPost(level LogLevel, p []byte, err error)
+}
+
+// PostHookFunc is an adaptor to allow the use of an ordinary function
+// as a LogPostHook.
+type PostHookFunc func(level LogLevel, p []byte, err error)
+
+// RunPost implements the LogPostHook interface.
+func (h PostHookFunc) RunPost(level LogLevel, p []byte, err error) {
+	h(level, p, err)
+}
+
+// eventPostHook is implemented by post hooks reading the written event. Its entry is
+// filled in if usesEntry returns true.
+type eventPostHook interface {
+	runPostEvent(e *Event, err error)
+	usesEntry() bool
+}
+
+// hookIndex returns the index at which a hook of the given priority is inserted among n
+// hooks, ordered by decreasing priority: after the hooks of the same priority.
+// Missing priorities are 0.
+func hookIndex(priorities []int, n int, priority int) int {
+	for i := n; i > 0; i-- {
+		p := 0
+		if i-1 < len(priorities) {
+			p = priorities[i-1]
+		}
+		if p >= priority {
+			return i
+		}
+	}
+	return 0
+}
+
+// insertPriority returns a copy of the priorities of n hooks with priority inserted at i.
+func insertPriority(priorities []int, n int, i int, priority int) []int {
+	ret := make([]int, n+1)
+	copy(ret, priorities)
+	copy(ret[i+1:], ret[i:n])
+	ret[i] = priority
+	return ret
+}
+
+func (l *Logger) addHook(hook LogHook, priority int) {
+	i := hookIndex(l.hookPriorities, len(l.hooks), priority)
+	hooks := make([]LogHook, 0, len(l.hooks)+1)
+	hooks = append(append(append(hooks, l.hooks[:i]...), hook), l.hooks[i:]...)
+	l.hookPriorities = insertPriority(l.hookPriorities, len(l.hooks), i, priority)
+	l.hooks = hooks
+}
+
+func (l *Logger) addPostHook(hook LogPostHook, priority int) {
+	i := hookIndex(l.postHookPriorities, len(l.postHooks), priority)
+	hooks := make([]LogPostHook, 0, len(l.postHooks)+1)
+	hooks = append(append(append(hooks, l.postHooks[:i]...), hook), l.postHooks[i:]...)
+	l.postHookPriorities = insertPriority(l.postHookPriorities, len(l.postHooks), i, priority)
+	l.setPostHooks(hooks)
+}
+
+func (l *Logger) setPostHooks(hooks []LogPostHook) {
+	l.postHooks = hooks
+	l.postHookEntry = false
+	for _, hook := range hooks {
+		if eventHook, ok := hook.(eventPostHook); ok && eventHook.usesEntry() {
+			l.postHookEntry = true
+		}
+	}
+}
+
+// LevelHook applies a different hook for each level. The hooks of custom levels are
+// looked up in CustomHooks by level.
 type LevelHook struct {
-	NoLevelHook, DebugHook, InfoHook, WarnHook, ErrorHook, FatalHook, PanicHook LogHook
+	NoLevelHook, TraceHook, DebugHook, InfoHook, WarnHook, ErrorHook, FatalHook, PanicHook LogHook
+
+	CustomHooks map[LogLevel]LogHook
 }
 
 // Run implements the Hook interface.
 func (h LevelHook) Run(e *Event, level LogLevel, message string) {
 	switch level {
+	case TraceLevel:
+		if h.TraceHook != nil {
+			h.TraceHook.Run(e, level, message)
+		}
 	case DebugLevel:
 		if h.DebugHook != nil {
 			h.DebugHook.Run(e, level, message)
@@ -51,6 +132,10 @@ func (h LevelHook) Run(e *Event, level LogLevel, message string) {
 		if h.NoLevelHook != nil {
 			h.NoLevelHook.Run(e, level, message)
 		}
+	default:
+		if hook := h.CustomHooks[level]; hook != nil {
+			hook.Run(e, level, message)
+		}
 	}
 }
 
@@ -0,0 +1,228 @@
+package rz
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultAsyncHookQueueSize is the default number of snapshots an AsyncHook can queue.
+	DefaultAsyncHookQueueSize = 1024
+	// DefaultAsyncHookWorkers is the default number of goroutines running an AsyncHook.
+	DefaultAsyncHookWorkers = 1
+)
+
+// EventSnapshot is an immutable copy of a written event, safe to keep once the event is
+// recycled.
+type EventSnapshot struct {
+	Level LogLevel
+	// Message is empty if the event has no message.
+	Message string
+	// Timestamp is the zero time if timestamps are disabled.
+	Timestamp time.Time
+	// Caller is the file:line of the caller, if enabled.
+	Caller string
+	// Fields are the context and event fields, in the order they were added. See
+	// EntryField for their types. Values which could be modified by the logging goroutine
+	// are copied: slices and maps of the types of EntryField are copied, LogObjectMarshaler
+	// values given to Any or Map are stored as []EntryField, and other values sharing
+	// memory, e.g. pointers, structs, or typed maps and slices, as their JSON encoding in a
+	// json.RawMessage. Errors are kept as is.
+	Fields []EntryField
+	// Bytes are the final bytes of the event, as given to the writer.
+	Bytes []byte
+	// Err is the write error, if any.
+	Err error
+}
+
+// Lookup returns the value of the top-level field key of the snapshot, and false if the
+// snapshot has no such field. If the key is set several times, the last value is returned.
+func (s *EventSnapshot) Lookup(key string) (interface{}, bool) {
+	for i := len(s.Fields) - 1; i >= 0; i-- {
+		if s.Fields[i].Key == key {
+			return s.Fields[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// AsyncHookOption is used to configure an AsyncHook.
+type AsyncHookOption func(h *AsyncHook)
+
+// AsyncHookQueueSize update the number of snapshots the hook can queue. Once the queue is
+// full, new snapshots are dropped.
+func AsyncHookQueueSize(size int) AsyncHookOption {
+	return func(h *AsyncHook) {
+		if size > 0 {
+			h.queue = make(chan EventSnapshot, size)
+		}
+	}
+}
+
+// AsyncHookWorkers update the number of goroutines running the hook.
+func AsyncHookWorkers(workers int) AsyncHookOption {
+	return func(h *AsyncHook) {
+		if workers > 0 {
+			h.workers = workers
+		}
+	}
+}
+
+// AsyncHook is a post hook running fn on a snapshot of each written event, from a pool
+// of worker goroutines, so slow hooks, e.g. doing network I/O, don't block the logging
+// goroutines. Snapshots are queued in a bounded queue and dropped when it's full.
+// Close must be called to run the hook on the queued snapshots before exiting.
+//
+// An AsyncHook is added to loggers with AddPostHook.
+type AsyncHook struct {
+	dropped uint64 // first for 64-bit alignment of atomic operations on 32-bit platforms
+	fn      func(s EventSnapshot)
+	queue   chan EventSnapshot
+	workers int
+	wg      sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewAsyncHook creates an AsyncHook running fn and starts its workers.
+func NewAsyncHook(fn func(s EventSnapshot), options ...AsyncHookOption) *AsyncHook {
+	h := &AsyncHook{
+		fn:      fn,
+		queue:   make(chan EventSnapshot, DefaultAsyncHookQueueSize),
+		workers: DefaultAsyncHookWorkers,
+	}
+	for _, option := range options {
+		option(h)
+	}
+	h.wg.Add(h.workers)
+	for i := 0; i < h.workers; i++ {
+		go h.run()
+	}
+	return h
+}
+
+// RunPost implements the LogPostHook interface. The snapshot only holds the level, the
+// bytes and the error: loggers call the hook with the whole event instead.
+func (h *AsyncHook) RunPost(level LogLevel, p []byte, err error) {
+	h.enqueue(EventSnapshot{Level: level, Bytes: append([]byte(nil), p...), Err: err})
+}
+
+func (h *AsyncHook) runPostEvent(e *Event, err error) {
+	h.enqueue(EventSnapshot{
+		Level:     e.level,
+		Message:   e.entry.Message,
+		Timestamp: e.entry.Timestamp,
+		Caller:    e.entry.Caller,
+		Fields:    snapshotFields(appendEntryFields(nil, e.records)),
+		Bytes:     append([]byte(nil), e.buf...),
+		Err:       err,
+	})
+}
+
+// snapshotFields makes fields independent from the logged values: values given as
+// interfaces, e.g. to Any or Map, are otherwise shared with the caller, which may modify
+// them once the event is written.
+func snapshotFields(fields []EntryField) []EntryField {
+	for i := range fields {
+		fields[i].Value = snapshotValue(fields[i].Value)
+	}
+	return fields
+}
+
+// snapshotValue returns a copy of value if it may share memory with the caller.
+func snapshotValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, time.Time, error:
+		return value
+	case []EntryField:
+		return snapshotFields(append(make([]EntryField, 0, len(v)), v...))
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = snapshotValue(v[i])
+		}
+		return values
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(v))
+		for key, element := range v {
+			values[key] = snapshotValue(element)
+		}
+		return values
+	case []string:
+		return append(make([]string, 0, len(v)), v...)
+	case []byte:
+		return append(make([]byte, 0, len(v)), v...)
+	case json.RawMessage:
+		return append(make(json.RawMessage, 0, len(v)), v...)
+	case net.IP:
+		return append(make(net.IP, 0, len(v)), v...)
+	case net.IPNet:
+		return net.IPNet{IP: append(net.IP(nil), v.IP...), Mask: append(net.IPMask(nil), v.Mask...)}
+	case net.HardwareAddr:
+		return append(make(net.HardwareAddr, 0, len(v)), v...)
+	case LogObjectMarshaler:
+		return snapshotFields(objectEntryFields(v))
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.String:
+		return value
+	}
+	if b, err := json.Marshal(value); err == nil {
+		return json.RawMessage(b)
+	}
+	return value
+}
+
+func (h *AsyncHook) usesEntry() bool {
+	return true
+}
+
+func (h *AsyncHook) enqueue(s EventSnapshot) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		atomic.AddUint64(&h.dropped, 1)
+		return
+	}
+	select {
+	case h.queue <- s:
+	default:
+		atomic.AddUint64(&h.dropped, 1)
+	}
+}
+
+// Dropped returns the number of snapshots dropped because the queue was full or the hook
+// was closed.
+func (h *AsyncHook) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// Close runs the hook on the queued snapshots and stops the workers. Events written after
+// Close are dropped.
+func (h *AsyncHook) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	close(h.queue)
+	h.mu.Unlock()
+
+	h.wg.Wait()
+	return nil
+}
+
+func (h *AsyncHook) run() {
+	defer h.wg.Done()
+	for s := range h.queue {
+		h.fn(s)
+	}
+}
@@ -0,0 +1,154 @@
+package rz
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAsyncHook(t *testing.T) {
+	var mu sync.Mutex
+	var snapshots []EventSnapshot
+	hook := NewAsyncHook(func(s EventSnapshot) {
+		mu.Lock()
+		snapshots = append(snapshots, s)
+		mu.Unlock()
+	}, AsyncHookWorkers(2))
+
+	log := New(Writer(&bytes.Buffer{}), Fields(Timestamp(false), String("service", "api")), AddPostHook(hook))
+	log.Error("failed", Err(errors.New("boom")), Int("user_id", 42))
+	log.Info("hello")
+	if err := hook.Close(); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("closed")
+
+	if len(snapshots) != 2 {
+		t.Fatalf("got %d snapshots, want 2", len(snapshots))
+	}
+	var s EventSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.Level == ErrorLevel {
+			s = snapshot
+		}
+	}
+	if s.Message != "failed" {
+		t.Errorf("snapshot message = %q, want %q", s.Message, "failed")
+	}
+	if userID, ok := s.Lookup("user_id"); !ok || userID != 42 {
+		t.Errorf("snapshot Lookup(user_id) = %v, %v, want 42, true", userID, ok)
+	}
+	if service, ok := s.Lookup("service"); !ok || service != "api" {
+		t.Errorf("snapshot Lookup(service) = %v, %v, want api, true", service, ok)
+	}
+	want := `{"level":"error","service":"api","error":"boom","user_id":42,"message":"failed"}` + "\n"
+	if got := string(s.Bytes); got != want {
+		t.Errorf("snapshot bytes:\ngot:  %v\nwant: %v", got, want)
+	}
+	if got := hook.Dropped(); got != 1 {
+		t.Errorf("Dropped() = %d, want 1", got)
+	}
+}
+
+func TestAsyncHookQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var messages []string
+	hook := NewAsyncHook(func(s EventSnapshot) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		messages = append(messages, s.Message)
+	}, AsyncHookQueueSize(1))
+
+	log := New(Writer(&bytes.Buffer{}), AddPostHook(hook))
+	log.Info("running")
+	<-started
+	log.Info("queued")
+	log.Info("dropped")
+	close(release)
+	hook.Close()
+
+	if got := hook.Dropped(); got != 1 {
+		t.Errorf("Dropped() = %d, want 1", got)
+	}
+	if len(messages) != 2 || messages[0] != "running" || messages[1] != "queued" {
+		t.Errorf("hook messages = %v, want [running queued]", messages)
+	}
+}
+
+func TestAsyncHookSnapshotReuse(t *testing.T) {
+	var snapshots []EventSnapshot
+	hook := NewAsyncHook(func(s EventSnapshot) {
+		snapshots = append(snapshots, s)
+	}, AsyncHookQueueSize(10))
+	log := New(Writer(&bytes.Buffer{}), Fields(Timestamp(false)), AddPostHook(hook))
+	for i := 0; i < 3; i++ {
+		log.Info("hello", Int("i", i))
+	}
+	hook.Close()
+
+	for i, s := range snapshots {
+		if v, _ := s.Lookup("i"); v != i {
+			t.Errorf("snapshot %d field i = %v, want %d", i, v, i)
+		}
+	}
+}
+
+func TestAsyncHookSnapshotCopy(t *testing.T) {
+	var s EventSnapshot
+	var text string
+	hook := NewAsyncHook(func(snapshot EventSnapshot) {
+		// read the values concurrently with their modification, for the race detector
+		text = fmt.Sprint(snapshot.Fields)
+		s = snapshot
+	})
+
+	tags := []string{"a", "b"}
+	data := []byte("data")
+	nested := map[string]interface{}{"tags": tags, "data": data}
+	counts := map[string]int{"a": 1}
+	ids := []int{1, 2}
+	user := &struct{ Name string }{"bob"}
+	login := &credentials{"bob", "hunter2"}
+	log := New(Writer(&bytes.Buffer{}), AddPostHook(hook))
+	log.Info("hello", Any("tags", tags), Any("data", data), Map(map[string]interface{}{"nested": nested}),
+		Dict("dict", log.NewDict(Any("tags", tags))), Any("counts", counts), Any("ids", ids), Any("user", user),
+		Any("login", login))
+	// the logged values are modified while the hook may run
+	tags[0] = "modified"
+	data[0] = 'D'
+	nested["tags"] = nil
+	counts["a"] = 2
+	ids[0] = 3
+	user.Name = "modified"
+	login.user = "modified"
+	hook.Close()
+
+	if strings.Contains(text, "modified") {
+		t.Errorf("the hook read a modified value: %s", text)
+	}
+
+	want := map[string]interface{}{
+		"tags":   []string{"a", "b"},
+		"data":   []byte("data"),
+		"nested": map[string]interface{}{"tags": []string{"a", "b"}, "data": []byte("data")},
+		"dict":   []EntryField{{"tags", []string{"a", "b"}}},
+		"counts": json.RawMessage(`{"a":1}`),
+		"ids":    json.RawMessage(`[1,2]`),
+		"user":   json.RawMessage(`{"Name":"bob"}`),
+		"login":  []EntryField{{"user", "bob"}, {"password", "hunter2"}},
+	}
+	for key, value := range want {
+		if got, _ := s.Lookup(key); !reflect.DeepEqual(got, value) {
+			t.Errorf("snapshot field %s = %#v, want %#v", key, got, value)
+		}
+	}
+}
@@ -2,8 +2,17 @@ package rz
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"net"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/skerkour/rz/cbor"
+	"github.com/skerkour/rz/logfmt"
 )
 
 var (
@@ -167,3 +176,245 @@ func BenchmarkHooks(b *testing.B) {
 		})
 	})
 }
+
+func TestHookFieldAccess(t *testing.T) {
+	var userID interface{}
+	var found bool
+	var keys []string
+	hook := HookFunc(func(e *Event, level LogLevel, message string) {
+		userID, found = e.Lookup("user_id")
+		e.Range(func(key string, value interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+	})
+	log := New(Writer(&bytes.Buffer{}), Fields(Timestamp(false), String("service", "api")), Hooks(hook))
+	log = log.With(Fields(Int("user_id", 1)))
+	log.Info("hello", Int("user_id", 42), Bool("admin", true))
+
+	if !found || userID != 42 {
+		t.Errorf("Lookup(user_id) = %v, %v, want 42, true", userID, found)
+	}
+	if got, want := strings.Join(keys, ","), "service,user_id,user_id,admin"; got != want {
+		t.Errorf("Range() keys = %s, want %s", got, want)
+	}
+}
+
+func TestHookRemoveFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoder Encoder
+		hook    HookFunc
+		want    string
+	}{
+		{"First", nil, func(e *Event, level LogLevel, message string) {
+			e.Remove("service")
+		}, `{"level":"info","a":1,"b":"x","message":"hello"}` + "\n"},
+		{"Middle", nil, func(e *Event, level LogLevel, message string) {
+			e.Remove("a")
+		}, `{"level":"info","service":"api","b":"x","message":"hello"}` + "\n"},
+		{"Last", nil, func(e *Event, level LogLevel, message string) {
+			e.Remove("b")
+		}, `{"level":"info","service":"api","a":1,"message":"hello"}` + "\n"},
+		{"All", nil, func(e *Event, level LogLevel, message string) {
+			e.Remove("service")
+			e.Remove("a")
+			e.Remove("b")
+		}, `{"level":"info","message":"hello"}` + "\n"},
+		{"Missing", nil, func(e *Event, level LogLevel, message string) {
+			if e.Remove("missing") {
+				e.Append(Bool("removed", true))
+			}
+		}, `{"level":"info","service":"api","a":1,"b":"x","message":"hello"}` + "\n"},
+		{"Replace", nil, func(e *Event, level LogLevel, message string) {
+			e.Replace("a", String("a", "redacted"))
+		}, `{"level":"info","service":"api","b":"x","a":"redacted","message":"hello"}` + "\n"},
+		{"Logfmt", logfmt.Encoder{}, func(e *Event, level LogLevel, message string) {
+			e.Remove("service")
+			e.Remove("b")
+		}, `level=info a=1 message=hello` + "\n"},
+		{"CBOR", cbor.Encoder{}, func(e *Event, level LogLevel, message string) {
+			e.Remove("service")
+			e.Replace("b", Int("b", 2))
+		}, `{"level":"info","a":1,"b":2,"message":"hello"}` + "\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			options := []LoggerOption{Writer(out), Fields(Timestamp(false), String("service", "api")), Hooks(tt.hook)}
+			if tt.encoder != nil {
+				options = append(options, WithEncoder(tt.encoder))
+			}
+			log := New(options...)
+			log.Info("hello", Int("a", 1), String("b", "x"))
+			got := out.String()
+			if tt.encoder == (cbor.Encoder{}) {
+				decoded, err := cbor.DecodeToJSON(out.Bytes())
+				if err != nil {
+					t.Fatalf("cbor.DecodeToJSON() returned error: %s", err)
+				}
+				got = string(decoded)
+			}
+			if got != tt.want {
+				t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHookRemoveMapFields(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(Writer(out), Fields(Timestamp(false)), Hooks(HookFunc(func(e *Event, level LogLevel, message string) {
+		e.Remove("b")
+		if _, ok := e.Lookup("b"); ok {
+			t.Error("Lookup(b) found a removed field")
+		}
+	})))
+	log.Info("", Map(map[string]interface{}{"a": 1, "b": 2, "c": 3}), Dict("d", log.NewDict(Int("b", 4))))
+	if got, want := out.String(), `{"level":"info","a":1,"c":3,"d":{"b":4}}`+"\n"; got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestHookPriority(t *testing.T) {
+	var order []string
+	hook := func(name string) LogHook {
+		return HookFunc(func(e *Event, level LogLevel, message string) {
+			order = append(order, name)
+		})
+	}
+	log := New(Writer(&bytes.Buffer{}),
+		AddHook(hook("a")),
+		AddHookPriority(hook("low"), -1),
+		AddHook(hook("b")),
+		AddHookPriority(hook("high"), 10),
+		AddHookPriority(hook("high2"), 10),
+	)
+	child := log.With(AddHook(hook("child")))
+	log.Info("")
+	if got, want := strings.Join(order, ","), "high,high2,a,b,low"; got != want {
+		t.Errorf("hooks order = %s, want %s", got, want)
+	}
+
+	order = nil
+	child.Info("")
+	if got, want := strings.Join(order, ","), "high,high2,a,b,child,low"; got != want {
+		t.Errorf("child hooks order = %s, want %s", got, want)
+	}
+}
+
+func TestPostHook(t *testing.T) {
+	type write struct {
+		level LogLevel
+		p     string
+		err   error
+	}
+	var writes []write
+	var order []string
+	postHook := PostHookFunc(func(level LogLevel, p []byte, err error) {
+		writes = append(writes, write{level, string(p), err})
+	})
+	orderHook := func(name string) LogPostHook {
+		return PostHookFunc(func(level LogLevel, p []byte, err error) {
+			order = append(order, name)
+		})
+	}
+
+	out := &bytes.Buffer{}
+	log := New(Writer(out), Fields(Timestamp(false)), Hooks(discardHook), AddPostHook(postHook),
+		AddPostHookPriority(orderHook("low"), -1), AddPostHookPriority(orderHook("high"), 1))
+	log.Info("discarded")
+	if len(writes) != 0 {
+		t.Errorf("post hooks ran for a discarded event: %v", writes)
+	}
+
+	log = log.With(Hooks(), Formatter(FormatterLogfmt()))
+	log.Warn("hello", Int("n", 1))
+	want := write{WarnLevel, "level=warning message=hello n=1\n", nil}
+	if len(writes) != 1 || writes[0] != want {
+		t.Errorf("post hooks writes = %v, want [%v]", writes, want)
+	}
+	if got, want := strings.Join(order, ","), "high,low"; got != want {
+		t.Errorf("post hooks order = %s, want %s", got, want)
+	}
+
+	errorHandler := ErrorHandler
+	defer func() { ErrorHandler = errorHandler }()
+	ErrorHandler = func(err error) {}
+	writeErr := errors.New("write error")
+	writes = nil
+	log = log.With(Writer(errWriter{writeErr}), PostHooks(postHook))
+	log.Error("failed")
+	if len(writes) != 1 || writes[0].err != writeErr || writes[0].level != ErrorLevel {
+		t.Errorf("post hooks writes = %v, want the write error", writes)
+	}
+}
+
+func TestHookFieldTypes(t *testing.T) {
+	var fields map[string]interface{}
+	hook := HookFunc(func(e *Event, level LogLevel, message string) {
+		fields = map[string]interface{}{}
+		e.Range(func(key string, value interface{}) bool {
+			fields[key] = value
+			return true
+		})
+	})
+	log := New(Writer(ioutil.Discard), Hooks(hook))
+	now := time.Date(2019, 2, 7, 9, 30, 7, 0, time.UTC)
+	_, ipNet, _ := net.ParseCIDR("10.0.0.0/8")
+	mac, _ := net.ParseMAC("00:00:5e:00:53:01")
+	b := []byte("bytes")
+	log.Info("",
+		Bool("bool", true),
+		Int8("int8", -8),
+		Uint16("uint16", 16),
+		Float32("float32", 1.5),
+		Duration("duration", time.Second),
+		Time("time", now),
+		Bytes("bytes", b),
+		Hex("hex", []byte{0xca, 0xfe}),
+		RawJSON("raw", []byte(`{"a":1}`)),
+		IP("ip", net.IPv4(127, 0, 0, 1)),
+		IPNet("ipnet", *ipNet),
+		HardwareAddr("mac", mac),
+		Ints8("ints8", []int8{-1, 2}),
+		Floats64("floats64", []float64{0.5}),
+		Times("times", []time.Time{now}),
+		Strings("strings", []string{}),
+		Errors("errors", []error{errors.New("a")}),
+		Dict("dict", log.NewDict(Uint("uint", 1), Strings("tags", []string{"x"}))),
+		Any("any", map[string]int{"n": 1}),
+	)
+	b[0] = 'B'
+
+	want := map[string]interface{}{
+		"bool":     true,
+		"int8":     int8(-8),
+		"uint16":   uint16(16),
+		"float32":  float32(1.5),
+		"duration": time.Second,
+		"time":     now,
+		"bytes":    []byte("bytes"),
+		"hex":      "cafe",
+		"raw":      json.RawMessage(`{"a":1}`),
+		"ip":       net.IPv4(127, 0, 0, 1),
+		"ipnet":    *ipNet,
+		"mac":      mac,
+		"ints8":    []int8{-1, 2},
+		"floats64": []float64{0.5},
+		"times":    []time.Time{now},
+		"strings":  []string{},
+		"errors":   []interface{}{"a"},
+		"dict":     []EntryField{{"uint", uint(1)}, {"tags", []string{"x"}}},
+		"any":      map[string]int{"n": 1},
+	}
+	for key, value := range want {
+		if got := fields[key]; !reflect.DeepEqual(got, value) {
+			t.Errorf("field %s = %#v, want %#v", key, got, value)
+		}
+	}
+	if len(fields) != len(want) {
+		t.Errorf("Range() returned %d fields, want %d", len(fields), len(want))
+	}
+}
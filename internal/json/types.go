@@ -370,6 +370,11 @@ func (e Encoder) AppendInterface(dst []byte, i interface{}) []byte {
 	return append(dst, marshaled...)
 }
 
+// AppendEmbeddedJSON adds already encoded JSON to dst.
+func (Encoder) AppendEmbeddedJSON(dst, j []byte) []byte {
+	return append(dst, j...)
+}
+
 // AppendObjectData takes in an object that is already in a byte array
 // and adds it to the dst.
 func (Encoder) AppendObjectData(dst []byte, o []byte) []byte {
@@ -24,11 +24,21 @@ func With(options ...rz.LoggerOption) rz.Logger {
 	return logger.With(options...)
 }
 
+// Named creates a child of the global logger with the given name.
+func Named(name string) rz.Logger {
+	return logger.Named(name).With(rz.CallerSkipFrameCount(3))
+}
+
 // LogWithLevel logs a new message with the given level.
 func LogWithLevel(level rz.LogLevel, message string, fields ...rz.Field) {
 	logger.LogWithLevel(level, message, fields...)
 }
 
+// Trace logs a new message with trace level.
+func Trace(message string, fields ...rz.Field) {
+	logger.Trace(message, fields...)
+}
+
 // Debug starts a new message with debug level.
 func Debug(message string, fields ...rz.Field) {
 	logger.Debug(message, fields...)
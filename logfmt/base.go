@@ -0,0 +1,51 @@
+// Package logfmt provides an rz.Encoder writing events in the logfmt format:
+// space separated key=value pairs, one event per line.
+// Nested objects and arrays are flattened using dotted keys.
+//
+//	logger := rz.New(rz.WithEncoder(logfmt.Encoder{}))
+//	logger.Info("hello", rz.Dict("user", logger.NewDict(rz.Int("id", 42))))
+//
+//	// Output: level=info user.id=42 message=hello
+package logfmt
+
+// Encoder is the logfmt encoder
+type Encoder struct{}
+
+// Events are first encoded with the following markers to delimit nested
+// structures, then flattened when the line break is appended.
+// Markers are control characters: any value containing one is quoted, so they
+// can't be confused with data.
+const (
+	objectStart = 0x01
+	objectEnd   = 0x02
+	arrayStart  = 0x03
+	arrayDelim  = 0x04
+	arrayEnd    = 0x05
+)
+
+// AppendKey appends a new key to the output logfmt.
+func (e Encoder) AppendKey(dst []byte, key string) []byte {
+	if len(dst) > 0 && dst[len(dst)-1] != objectStart {
+		dst = append(dst, ' ')
+	}
+	dst = e.AppendString(dst, key)
+	return append(dst, '=')
+}
+
+func needsQuote(s string) bool {
+	for i := range s {
+		if s[i] < 0x20 || s[i] > 0x7e || s[i] == ' ' || s[i] == '\\' || s[i] == '"' {
+			return true
+		}
+	}
+	return false
+}
+
+func needsQuoteBytes(s []byte) bool {
+	for i := range s {
+		if s[i] < 0x20 || s[i] > 0x7e || s[i] == ' ' || s[i] == '\\' || s[i] == '"' {
+			return true
+		}
+	}
+	return false
+}
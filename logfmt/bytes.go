@@ -0,0 +1,32 @@
+package logfmt
+
+import "strconv"
+
+// AppendBytes is a mirror of AppendString with []byte arg
+func (Encoder) AppendBytes(dst, s []byte) []byte {
+	if len(s) == 0 {
+		return append(dst, '"', '"')
+	}
+	if needsQuoteBytes(s) {
+		return strconv.AppendQuote(dst, string(s))
+	}
+	return append(dst, s...)
+}
+
+// AppendHex encodes the input bytes to a hex string and appends
+// the encoded string to the input byte slice.
+func (Encoder) AppendHex(dst, s []byte) []byte {
+	const hex = "0123456789abcdef"
+	if len(s) == 0 {
+		return append(dst, '"', '"')
+	}
+	for _, v := range s {
+		dst = append(dst, hex[v>>4], hex[v&0x0f])
+	}
+	return dst
+}
+
+// AppendEmbeddedJSON adds already encoded JSON to dst, as a single value.
+func (e Encoder) AppendEmbeddedJSON(dst, j []byte) []byte {
+	return e.AppendBytes(dst, j)
+}
@@ -0,0 +1,164 @@
+package logfmt
+
+import "strconv"
+
+type frame struct {
+	// pathLen is the length of the key path of the object or array.
+	pathLen int
+	array   bool
+	index   int
+	empty   bool
+}
+
+// flatten rewrites the marked up event in dst as logfmt pairs,
+// nested keys being joined with dots and array elements being keyed by index.
+//
+// The output is first appended after the input and then moved to the start
+// of dst, so flattening does not allocate when dst has enough capacity.
+func flatten(dst []byte) []byte {
+	if isFlat(dst) {
+		n := copy(dst, dst[1:len(dst)-1])
+		return dst[:n]
+	}
+
+	in := dst
+	start := len(dst)
+	var pathBuf [128]byte
+	path := pathBuf[:0]
+	var stackBuf [16]frame
+	stack := stackBuf[:0]
+
+	for i := 0; i < len(in); {
+		c := in[i]
+		switch c {
+		case objectStart, arrayStart:
+			if len(stack) > 0 && stack[len(stack)-1].array {
+				// structure nested in an array: key it by its index.
+				path = appendIndex(path, &stack[len(stack)-1])
+			}
+			stack = append(stack, frame{pathLen: len(path), array: c == arrayStart, empty: true})
+			i++
+			continue
+		case objectEnd, arrayEnd:
+			if len(stack) > 0 {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if top.empty && top.pathLen > 0 {
+					dst = appendPair(dst, start, path[:top.pathLen], emptyValue(c))
+				}
+				if len(stack) > 0 {
+					path = path[:stack[len(stack)-1].pathLen]
+				} else {
+					path = path[:0]
+				}
+			}
+			i++
+			continue
+		case arrayDelim:
+			if len(stack) > 0 {
+				stack[len(stack)-1].index++
+			}
+			i++
+			continue
+		case ' ':
+			i++
+			continue
+		}
+
+		if len(stack) > 0 && stack[len(stack)-1].array {
+			path = appendIndex(path, &stack[len(stack)-1])
+		} else {
+			if len(stack) > 0 {
+				stack[len(stack)-1].empty = false
+				path = path[:stack[len(stack)-1].pathLen]
+			}
+			if len(path) > 0 {
+				path = append(path, '.')
+			}
+			end := scanToken(in, i, '=')
+			path = append(path, in[i:end]...)
+			i = end + 1
+			if i >= len(in) {
+				break
+			}
+		}
+
+		switch in[i] {
+		case objectStart, arrayStart:
+			// the value is a nested structure: keep the path for its children.
+			continue
+		}
+		end := scanToken(in, i, ' ')
+		dst = appendPair(dst, start, path, in[i:end])
+		i = end
+	}
+
+	n := copy(dst, dst[start:])
+	return dst[:n]
+}
+
+// appendIndex sets path to the key of the current element of the array top.
+func appendIndex(path []byte, top *frame) []byte {
+	top.empty = false
+	path = path[:top.pathLen]
+	if len(path) > 0 {
+		path = append(path, '.')
+	}
+	return strconv.AppendInt(path, int64(top.index), 10)
+}
+
+// isFlat returns true if dst is a single object without nested structures.
+func isFlat(dst []byte) bool {
+	if len(dst) < 2 || dst[0] != objectStart || dst[len(dst)-1] != objectEnd {
+		return false
+	}
+	for _, c := range dst[1 : len(dst)-1] {
+		if c < 0x20 {
+			return false
+		}
+	}
+	return true
+}
+
+func appendPair(dst []byte, start int, key, value []byte) []byte {
+	if len(dst) > start {
+		dst = append(dst, ' ')
+	}
+	dst = append(dst, key...)
+	dst = append(dst, '=')
+	return append(dst, value...)
+}
+
+var (
+	emptyArray  = []byte("[]")
+	emptyObject = []byte("{}")
+)
+
+func emptyValue(end byte) []byte {
+	if end == arrayEnd {
+		return emptyArray
+	}
+	return emptyObject
+}
+
+// scanToken returns the end of the token starting at i: a quoted string,
+// or bytes up to sep or a marker.
+func scanToken(in []byte, i int, sep byte) int {
+	if i < len(in) && in[i] == '"' {
+		for j := i + 1; j < len(in); j++ {
+			switch in[j] {
+			case '\\':
+				j++
+			case '"':
+				return j + 1
+			}
+		}
+		return len(in)
+	}
+	for j := i; j < len(in); j++ {
+		if in[j] == sep || in[j] < 0x20 {
+			return j
+		}
+	}
+	return len(in)
+}
@@ -0,0 +1,75 @@
+package logfmt
+
+import (
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	obj := func(dst []byte, fields ...func([]byte) []byte) []byte {
+		dst = enc.AppendBeginMarker(dst)
+		for _, field := range fields {
+			dst = field(dst)
+		}
+		return enc.AppendEndMarker(dst)
+	}
+	str := func(key, val string) func([]byte) []byte {
+		return func(dst []byte) []byte {
+			return enc.AppendString(enc.AppendKey(dst, key), val)
+		}
+	}
+	nested := func(key string, fields ...func([]byte) []byte) func([]byte) []byte {
+		return func(dst []byte) []byte {
+			return obj(enc.AppendKey(dst, key), fields...)
+		}
+	}
+	ints := func(key string, vals ...int) func([]byte) []byte {
+		return func(dst []byte) []byte {
+			return enc.AppendInts(enc.AppendKey(dst, key), vals)
+		}
+	}
+
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"empty", obj(nil), "\n"},
+		{"flat", obj(nil, str("a", "b"), str("c", "d e")), `a=b c="d e"` + "\n"},
+		{"nested", obj(nil, str("a", "b"), nested("user", str("id", "42"), nested("address", str("city", "Paris"))), str("c", "d")),
+			`a=b user.id=42 user.address.city=Paris c=d` + "\n"},
+		{"array", obj(nil, ints("ints", 1, 2, 3), str("a", "b")), `ints.0=1 ints.1=2 ints.2=3 a=b` + "\n"},
+		{"empty containers", obj(nil, ints("ints"), nested("obj"), str("a", "b")), `ints=[] obj={} a=b` + "\n"},
+		{"quoted", obj(nil, nested("user", str("name", "J. \"Doe\""), str("id", "42"))), `user.name="J. \"Doe\"" user.id=42` + "\n"},
+		{"array of objects", obj(nil, func(dst []byte) []byte {
+			dst = enc.AppendArrayStart(enc.AppendKey(dst, "users"))
+			dst = obj(dst, str("id", "1"))
+			dst = enc.AppendArrayDelim(dst)
+			dst = obj(dst, str("id", "2"), ints("roles", 3))
+			return enc.AppendArrayEnd(dst)
+		}), `users.0.id=1 users.1.id=2 users.1.roles.0=3` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(enc.AppendLineBreak(tt.in)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlattenAllocs(t *testing.T) {
+	buf := make([]byte, 0, 500)
+	allocs := testing.AllocsPerRun(100, func() {
+		dst := enc.AppendBeginMarker(buf[:0])
+		dst = enc.AppendString(enc.AppendKey(dst, "level"), "info")
+		dst = enc.AppendInts(enc.AppendKey(dst, "ints"), []int{1, 2})
+		dst = enc.AppendBeginMarker(enc.AppendKey(dst, "user"))
+		dst = enc.AppendInt(enc.AppendKey(dst, "id"), 42)
+		dst = enc.AppendEndMarker(dst)
+		dst = enc.AppendEndMarker(dst)
+		enc.AppendLineBreak(dst)
+	})
+	if allocs != 0 {
+		t.Errorf("flattening allocated %v times, want 0", allocs)
+	}
+}
@@ -0,0 +1,31 @@
+package logfmt
+
+import "strconv"
+
+// AppendStrings encodes the input strings to logfmt and
+// appends the encoded string list to the input byte slice.
+func (e Encoder) AppendStrings(dst []byte, vals []string) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = e.AppendString(dst, val)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendString encodes the input string to logfmt and appends
+// the encoded string to the input byte slice.
+//
+// The string is quoted if it is empty or contains spaces, quotes,
+// backslashes or non printable ASCII characters.
+func (Encoder) AppendString(dst []byte, s string) []byte {
+	if len(s) == 0 {
+		return append(dst, '"', '"')
+	}
+	if needsQuote(s) {
+		return strconv.AppendQuote(dst, s)
+	}
+	return append(dst, s...)
+}
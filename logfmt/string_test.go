@@ -0,0 +1,62 @@
+package logfmt
+
+import (
+	"testing"
+)
+
+var enc = Encoder{}
+
+var encodeStringTests = []struct {
+	in  string
+	out string
+}{
+	{"", `""`},
+	{"ascii", `ascii`},
+	{"a=b", `a=b`},
+	{"with space", `"with space"`},
+	{"\\", `"\\"`},
+	{"\"a", `"\"a"`},
+	{"\x00", `"\x00"`},
+	{"\x01", `"\x01"`},
+	{"\x1f", `"\x1f"`},
+	{"\t", `"\t"`},
+	{"\n", `"\n"`},
+	{"✭", `"✭"`},
+	{"foo\xc2\x7fbar", `"foo\xc2\x7fbar"`}, // invalid sequence
+}
+
+func TestAppendString(t *testing.T) {
+	for _, tt := range encodeStringTests {
+		b := enc.AppendString([]byte{}, tt.in)
+		if got, want := string(b), tt.out; got != want {
+			t.Errorf("appendString(%q) = %#q, want %#q", tt.in, got, want)
+		}
+	}
+}
+
+func TestAppendBytes(t *testing.T) {
+	for _, tt := range encodeStringTests {
+		b := enc.AppendBytes([]byte{}, []byte(tt.in))
+		if got, want := string(b), tt.out; got != want {
+			t.Errorf("appendBytes(%q) = %#q, want %#q", tt.in, got, want)
+		}
+	}
+}
+
+func TestAppendKey(t *testing.T) {
+	tests := []struct {
+		dst  string
+		key  string
+		want string
+	}{
+		{"", "foo", `foo=`},
+		{"\x01", "foo", "\x01foo="},
+		{"a=b", "foo", `a=b foo=`},
+		{"", "foo bar", `"foo bar"=`},
+	}
+	for _, tt := range tests {
+		if got := string(enc.AppendKey([]byte(tt.dst), tt.key)); got != tt.want {
+			t.Errorf("AppendKey(%q, %q) = %q, want %q", tt.dst, tt.key, got, tt.want)
+		}
+	}
+}
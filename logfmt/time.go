@@ -0,0 +1,58 @@
+package logfmt
+
+import (
+	"strconv"
+	"time"
+)
+
+// AppendTime formats the input time with the given format
+// and appends the encoded string to the input byte slice.
+// If format is empty, the time is encoded as an UNIX timestamp.
+func (e Encoder) AppendTime(dst []byte, t time.Time, format string) []byte {
+	if format == "" {
+		return e.AppendInt64(dst, t.Unix())
+	}
+	start := len(dst)
+	dst = t.AppendFormat(dst, format)
+	if needsQuoteBytes(dst[start:]) {
+		var buf [64]byte
+		formatted := append(buf[:0], dst[start:]...)
+		dst = strconv.AppendQuote(dst[:start], string(formatted))
+	}
+	return dst
+}
+
+// AppendTimes converts the input times with the given format
+// and appends the encoded string list to the input byte slice.
+func (e Encoder) AppendTimes(dst []byte, vals []time.Time, format string) []byte {
+	dst = append(dst, arrayStart)
+	for i, t := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = e.AppendTime(dst, t, format)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendDuration formats the input duration with the given unit & format
+// and appends the encoded string to the input byte slice.
+func (e Encoder) AppendDuration(dst []byte, d time.Duration, unit time.Duration, useInt bool) []byte {
+	if useInt {
+		return strconv.AppendInt(dst, int64(d/unit), 10)
+	}
+	return e.AppendFloat64(dst, float64(d)/float64(unit))
+}
+
+// AppendDurations formats the input durations with the given unit & format
+// and appends the encoded string list to the input byte slice.
+func (e Encoder) AppendDurations(dst []byte, vals []time.Duration, unit time.Duration, useInt bool) []byte {
+	dst = append(dst, arrayStart)
+	for i, d := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = e.AppendDuration(dst, d, unit, useInt)
+	}
+	return append(dst, arrayEnd)
+}
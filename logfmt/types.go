@@ -0,0 +1,346 @@
+package logfmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+)
+
+// AppendNil inserts a 'Nil' object into the dst byte array.
+func (Encoder) AppendNil(dst []byte) []byte {
+	return append(dst, "null"...)
+}
+
+// AppendBeginMarker inserts a map start into the dst byte array.
+func (Encoder) AppendBeginMarker(dst []byte) []byte {
+	return append(dst, objectStart)
+}
+
+// AppendEndMarker inserts a map end into the dst byte array.
+func (Encoder) AppendEndMarker(dst []byte) []byte {
+	return append(dst, objectEnd)
+}
+
+// AppendLineBreak flattens the event and appends a line break.
+func (Encoder) AppendLineBreak(dst []byte) []byte {
+	return append(flatten(dst), '\n')
+}
+
+// AppendArrayStart adds markers to indicate the start of an array.
+func (Encoder) AppendArrayStart(dst []byte) []byte {
+	return append(dst, arrayStart)
+}
+
+// AppendArrayEnd adds markers to indicate the end of an array.
+func (Encoder) AppendArrayEnd(dst []byte) []byte {
+	return append(dst, arrayEnd)
+}
+
+// AppendArrayDelim adds markers to indicate end of a particular array element.
+func (Encoder) AppendArrayDelim(dst []byte) []byte {
+	if len(dst) > 0 {
+		return append(dst, arrayDelim)
+	}
+	return dst
+}
+
+// AppendBool converts the input bool to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendBool(dst []byte, val bool) []byte {
+	return strconv.AppendBool(dst, val)
+}
+
+// AppendBools encodes the input bools to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendBools(dst []byte, vals []bool) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = strconv.AppendBool(dst, val)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendInt converts the input int to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendInt(dst []byte, val int) []byte {
+	return strconv.AppendInt(dst, int64(val), 10)
+}
+
+// AppendInts encodes the input ints to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendInts(dst []byte, vals []int) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = strconv.AppendInt(dst, int64(val), 10)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendInt8 converts the input int8 to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendInt8(dst []byte, val int8) []byte {
+	return strconv.AppendInt(dst, int64(val), 10)
+}
+
+// AppendInts8 encodes the input int8s to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendInts8(dst []byte, vals []int8) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = strconv.AppendInt(dst, int64(val), 10)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendInt16 converts the input int16 to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendInt16(dst []byte, val int16) []byte {
+	return strconv.AppendInt(dst, int64(val), 10)
+}
+
+// AppendInts16 encodes the input int16s to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendInts16(dst []byte, vals []int16) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = strconv.AppendInt(dst, int64(val), 10)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendInt32 converts the input int32 to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendInt32(dst []byte, val int32) []byte {
+	return strconv.AppendInt(dst, int64(val), 10)
+}
+
+// AppendInts32 encodes the input int32s to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendInts32(dst []byte, vals []int32) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = strconv.AppendInt(dst, int64(val), 10)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendInt64 converts the input int64 to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendInt64(dst []byte, val int64) []byte {
+	return strconv.AppendInt(dst, val, 10)
+}
+
+// AppendInts64 encodes the input int64s to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendInts64(dst []byte, vals []int64) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = strconv.AppendInt(dst, val, 10)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendUint converts the input uint to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendUint(dst []byte, val uint) []byte {
+	return strconv.AppendUint(dst, uint64(val), 10)
+}
+
+// AppendUints encodes the input uints to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendUints(dst []byte, vals []uint) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = strconv.AppendUint(dst, uint64(val), 10)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendUint8 converts the input uint8 to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendUint8(dst []byte, val uint8) []byte {
+	return strconv.AppendUint(dst, uint64(val), 10)
+}
+
+// AppendUints8 encodes the input uint8s to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendUints8(dst []byte, vals []uint8) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = strconv.AppendUint(dst, uint64(val), 10)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendUint16 converts the input uint16 to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendUint16(dst []byte, val uint16) []byte {
+	return strconv.AppendUint(dst, uint64(val), 10)
+}
+
+// AppendUints16 encodes the input uint16s to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendUints16(dst []byte, vals []uint16) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = strconv.AppendUint(dst, uint64(val), 10)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendUint32 converts the input uint32 to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendUint32(dst []byte, val uint32) []byte {
+	return strconv.AppendUint(dst, uint64(val), 10)
+}
+
+// AppendUints32 encodes the input uint32s to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendUints32(dst []byte, vals []uint32) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = strconv.AppendUint(dst, uint64(val), 10)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendUint64 converts the input uint64 to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendUint64(dst []byte, val uint64) []byte {
+	return strconv.AppendUint(dst, val, 10)
+}
+
+// AppendUints64 encodes the input uint64s to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendUints64(dst []byte, vals []uint64) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = strconv.AppendUint(dst, val, 10)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendFloat32 converts the input float32 to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendFloat32(dst []byte, val float32) []byte {
+	return appendFloat(dst, float64(val), 32)
+}
+
+// AppendFloats32 encodes the input float32s to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendFloats32(dst []byte, vals []float32) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = appendFloat(dst, float64(val), 32)
+	}
+	return append(dst, arrayEnd)
+}
+
+// AppendFloat64 converts the input float64 to a string and
+// appends the encoded string to the input byte slice.
+func (Encoder) AppendFloat64(dst []byte, val float64) []byte {
+	return appendFloat(dst, val, 64)
+}
+
+// AppendFloats64 encodes the input float64s to logfmt and
+// appends the encoded string list to the input byte slice.
+func (Encoder) AppendFloats64(dst []byte, vals []float64) []byte {
+	dst = append(dst, arrayStart)
+	for i, val := range vals {
+		if i > 0 {
+			dst = append(dst, arrayDelim)
+		}
+		dst = appendFloat(dst, val, 64)
+	}
+	return append(dst, arrayEnd)
+}
+
+func appendFloat(dst []byte, val float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(val):
+		return append(dst, "NaN"...)
+	case math.IsInf(val, 1):
+		return append(dst, "+Inf"...)
+	case math.IsInf(val, -1):
+		return append(dst, "-Inf"...)
+	}
+	return strconv.AppendFloat(dst, val, 'f', -1, bitSize)
+}
+
+// AppendInterface marshals the input interface to JSON and
+// appends it as a single value to the input byte slice.
+func (e Encoder) AppendInterface(dst []byte, i interface{}) []byte {
+	if s, ok := i.(string); ok {
+		return e.AppendString(dst, s)
+	}
+	marshaled, err := json.Marshal(i)
+	if err != nil {
+		return e.AppendString(dst, fmt.Sprintf("marshaling error: %v", err))
+	}
+	return e.AppendBytes(dst, marshaled)
+}
+
+// AppendObjectData takes in an object that is already in a byte array
+// and adds it to the dst.
+func (Encoder) AppendObjectData(dst []byte, o []byte) []byte {
+	if len(o) > 0 && o[0] == objectStart {
+		o = o[1:]
+	}
+	if len(dst) > 0 && dst[len(dst)-1] != objectStart && len(o) > 0 {
+		dst = append(dst, ' ')
+	}
+	return append(dst, o...)
+}
+
+// AppendIPAddr adds IPv4 or IPv6 address to dst.
+func (e Encoder) AppendIPAddr(dst []byte, ip net.IP) []byte {
+	return e.AppendString(dst, ip.String())
+}
+
+// AppendIPPrefix adds IPv4 or IPv6 Prefix (address & mask) to dst.
+func (e Encoder) AppendIPPrefix(dst []byte, pfx net.IPNet) []byte {
+	return e.AppendString(dst, pfx.String())
+}
+
+// AppendMACAddr adds MAC address to dst.
+func (e Encoder) AppendMACAddr(dst []byte, ha net.HardwareAddr) []byte {
+	return e.AppendString(dst, ha.String())
+}
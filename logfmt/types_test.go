@@ -0,0 +1,44 @@
+package logfmt
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAppendType(t *testing.T) {
+	tests := []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{"AppendInt8(math.MinInt8)", enc.AppendInt8(nil, math.MinInt8), `-128`},
+		{"AppendInt64(math.MaxInt64)", enc.AppendInt64(nil, math.MaxInt64), `9223372036854775807`},
+		{"AppendUint64(math.MaxUint64)", enc.AppendUint64(nil, math.MaxUint64), `18446744073709551615`},
+		{"AppendFloat32(NaN)", enc.AppendFloat32(nil, float32(math.NaN())), `NaN`},
+		{"AppendFloat64(-Inf)", enc.AppendFloat64(nil, math.Inf(-1)), `-Inf`},
+		{"AppendFloat64(-1.1)", enc.AppendFloat64(nil, -1.1), `-1.1`},
+		{"AppendBool(true)", enc.AppendBool(nil, true), `true`},
+		{"AppendNil()", enc.AppendNil(nil), `null`},
+		{"AppendHex()", enc.AppendHex(nil, []byte{0x12, 0xef}), `12ef`},
+		{"AppendInterface(string)", enc.AppendInterface(nil, "foo"), `foo`},
+		{"AppendInterface(map)", enc.AppendInterface(nil, map[string]int{"a": 1}), `"{\"a\":1}"`},
+		{"AppendEmbeddedJSON()", enc.AppendEmbeddedJSON(nil, []byte(`[1,2]`)), `[1,2]`},
+		{"AppendDuration(float)", enc.AppendDuration(nil, 1500*time.Microsecond, time.Millisecond, false), `1.5`},
+		{"AppendDuration(int)", enc.AppendDuration(nil, 1500*time.Microsecond, time.Millisecond, true), `1`},
+		{"AppendTime(RFC3339)", enc.AppendTime(nil, time.Time{}, time.RFC3339), `0001-01-01T00:00:00Z`},
+		{"AppendTime(RFC1123)", enc.AppendTime(nil, time.Time{}, time.RFC1123), `"Mon, 01 Jan 0001 00:00:00 UTC"`},
+		{"AppendTime(unix)", enc.AppendTime(nil, time.Unix(42, 0), ""), `42`},
+		{"AppendIPAddr()", enc.AppendIPAddr(nil, net.IP{192, 0, 2, 200}), `192.0.2.200`},
+		{"AppendIPPrefix()", enc.AppendIPPrefix(nil, net.IPNet{IP: net.IP{192, 0, 2, 200}, Mask: net.IPv4Mask(255, 255, 255, 0)}), `192.0.2.200/24`},
+		{"AppendMACAddr()", enc.AppendMACAddr(nil, net.HardwareAddr{0x12, 0x34, 0x56, 0x78, 0x90, 0xab}), `12:34:56:78:90:ab`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.got); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -18,18 +18,35 @@ import (
 // you may consider a sync wrapper.
 type Logger struct {
 	writer               LevelWriter
+	writerEntry          bool
+	buffered             bool
 	stack                bool
 	caller               bool
 	timestamp            bool
 	level                LogLevel
+	levelRef             *AtomicLevel
+	filter               *LevelFilter
+	name                 string
 	sampler              LogSampler
+	eventSampler         EventSampler
+	sampleEntry          bool
+	stats                *SamplingStats
+	trace                *traceDecision
 	context              []byte
+	contextRecords       []entryField
+	redactor             *Redactor
 	hooks                []LogHook
+	hookPriorities       []int
+	postHooks            []LogPostHook
+	postHookPriorities   []int
+	postHookEntry        bool
 	timestampFieldName   string
 	levelFieldName       string
+	levelFormat          LevelFormat
 	messageFieldName     string
 	errorFieldName       string
 	callerFieldName      string
+	componentFieldName   string
 	callerSkipFrameCount int
 	errorStackFieldName  string
 	timeFieldFormat      string
@@ -56,6 +73,7 @@ func New(options ...LoggerOption) Logger {
 		messageFieldName:     DefaultMessageFieldName,
 		errorFieldName:       DefaultErrorFieldName,
 		callerFieldName:      DefaultCallerFieldName,
+		componentFieldName:   DefaultComponentFieldName,
 		callerSkipFrameCount: DefaultCallerSkipFrameCount,
 		errorStackFieldName:  DefaultErrorStackFieldName,
 		timeFieldFormat:      DefaultTimeFieldFormat,
@@ -79,14 +97,36 @@ func (l Logger) With(options ...LoggerOption) Logger {
 	if oldContext != nil {
 		l.context = append(l.context, oldContext...)
 	}
+	// appending to the copy's context fields must not overwrite the original's ones
+	l.contextRecords = l.contextRecords[:len(l.contextRecords):len(l.contextRecords)]
 	for _, option := range options {
 		option(&l)
 	}
 	return l
 }
 
+// Named creates a child logger whose name is the logger's name followed by name, dot
+// separated, e.g. "db.pool". The name is logged in the logger.componentFieldName field
+// and used by the logger's LevelFilter, if any, to resolve its level.
+func (l Logger) Named(name string) Logger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	l = l.With()
+	l.name = name
+	return l
+}
+
+// Name returns the name of the logger.
+func (l *Logger) Name() string {
+	return l.name
+}
+
 // GetLevel returns the current log level.
 func (l *Logger) GetLevel() LogLevel {
+	if l.levelRef != nil {
+		return l.levelRef.Level()
+	}
 	return l.level
 }
 
@@ -95,6 +135,11 @@ func (l *Logger) LogWithLevel(level LogLevel, message string, fields ...Field) {
 	l.logEvent(level, message, nil, fields)
 }
 
+// Trace logs a new message with trace level.
+func (l *Logger) Trace(message string, fields ...Field) {
+	l.logEvent(TraceLevel, message, nil, fields)
+}
+
 // Debug logs a new message with debug level.
 func (l *Logger) Debug(message string, fields ...Field) {
 	l.logEvent(DebugLevel, message, nil, fields)
@@ -137,7 +182,7 @@ func (l *Logger) Log(message string, fields ...Field) {
 // Call usual field methods like Str, Int etc to add fields to this
 // event and give it as argument the *Event.Dict method.
 func (l *Logger) NewDict(fields ...Field) *Event {
-	e := newEvent(nil, 0)
+	e := newEvent(nil, 0, l.encoder)
 	copyInternalLoggerFieldsToEvent(l, e)
 	e.Append(fields...)
 	return e
@@ -165,24 +210,46 @@ func (l *Logger) logEvent(level LogLevel, message string, done func(string), fie
 	if !enabled {
 		return
 	}
-	e := newEvent(l.writer, level)
+	e := newEvent(l.writer, level, l.encoder)
 	e.ch = l.hooks
+	e.postHooks = l.postHooks
 	copyInternalLoggerFieldsToEvent(l, e)
 	if level != NoLevel {
-		e.string(e.levelFieldName, level.String())
+		e.appendLevel(level, l.levelFormat)
+	}
+	e.fieldStart = len(e.buf)
+	if l.name != "" && l.componentFieldName != "" {
+		e.string(l.componentFieldName, l.name)
 	}
-	if l.context != nil && len(l.context) > 0 {
-		e.buf = enc.AppendObjectData(e.buf, l.context)
+	if e.withEntry {
+		if len(l.context) > 0 {
+			e.buf, e.records = appendContextData(e.encoder, e.buf, e.records, l.context, l.contextRecords)
+		}
+		e.fieldStart = len(e.buf)
+	} else if len(l.context) > 0 {
+		e.buf = e.encoder.AppendObjectData(e.buf, l.context)
 	}
+	// context fields are redacted once for all
+	redactFrom := len(e.records)
 
 	for i := range fields {
 		fields[i](e)
 	}
+	if l.redactor != nil {
+		l.redactor.redactEvent(e, redactFrom)
+	}
+
+	if l.eventSampler != nil && l.trace == nil && !l.eventSampler.SampleEvent(level, message, e) {
+		l.stats.drop(level, DropSampler)
+		putEvent(e)
+		return
+	}
 
 	writeEvent(e, message, done)
 }
 
 func writeEvent(e *Event, msg string, done func(string)) {
+	level := e.level
 	// run hooks
 	if len(e.ch) > 0 {
 		e.ch[0].Run(e, e.level, msg)
@@ -201,29 +268,53 @@ func writeEvent(e *Event, msg string, done func(string)) {
 	if e.level != Disabled {
 		var err error
 
+		e.stats.keep(level)
+
 		if e.timestamp {
-			e.buf = enc.AppendTime(enc.AppendKey(e.buf, e.timestampFieldName), e.timestampFunc(), e.timeFieldFormat)
+			timestamp := e.timestampFunc()
+			e.buf = e.encoder.AppendTime(e.encoder.AppendKey(e.buf, e.timestampFieldName), timestamp, e.timeFieldFormat)
+			e.entry.Timestamp = timestamp
 		}
 
 		if msg != "" {
-			e.buf = enc.AppendString(enc.AppendKey(e.buf, e.messageFieldName), msg)
+			e.buf = e.encoder.AppendString(e.encoder.AppendKey(e.buf, e.messageFieldName), msg)
 		}
 		if e.caller {
 			_, file, line, ok := runtime.Caller(e.callerSkipFrameCount)
 			if ok {
-				e.buf = enc.AppendString(enc.AppendKey(e.buf, e.callerFieldName), file+":"+strconv.Itoa(line))
+				caller := file + ":" + strconv.Itoa(line)
+				e.buf = e.encoder.AppendString(e.encoder.AppendKey(e.buf, e.callerFieldName), caller)
+				e.entry.Caller = caller
 			}
 		}
+		if e.withEntry {
+			e.entry.Level = e.level
+			e.entry.Message = msg
+			e.entry.timestampFieldName = e.timestampFieldName
+			e.entry.levelFieldName = e.levelFieldName
+			e.entry.messageFieldName = e.messageFieldName
+			e.entry.callerFieldName = e.callerFieldName
+			e.entry.timeFieldFormat = e.timeFieldFormat
+		}
 
 		// end json payload
-		e.buf = enc.AppendEndMarker(e.buf)
-		e.buf = enc.AppendLineBreak(e.buf)
+		e.buf = e.encoder.AppendEndMarker(e.buf)
+		e.buf = e.encoder.AppendLineBreak(e.buf)
 		if e.formatter != nil {
 			e.buf, err = e.formatter(e)
 		}
-		if e.w != nil {
+		if ew, ok := e.w.(eventWriter); ok {
+			_, err = ew.writeEvent(e)
+		} else if e.w != nil {
 			_, err = e.w.WriteLevel(e.level, e.buf)
 		}
+		for _, hook := range e.postHooks {
+			if eventHook, ok := hook.(eventPostHook); ok {
+				eventHook.runPostEvent(e, err)
+			} else {
+				hook.RunPost(e.level, e.buf, err)
+			}
+		}
 
 		putEvent(e)
 
@@ -234,17 +325,45 @@ func writeEvent(e *Event, msg string, done func(string)) {
 				fmt.Fprintf(os.Stderr, "rz: could not write event: %v\n", err)
 			}
 		}
+	} else {
+		e.stats.drop(level, DropDiscard)
 	}
+}
 
+// effectiveLevel returns the level of the logger, as resolved by its filter if any.
+func (l *Logger) effectiveLevel() LogLevel {
+	if l.filter != nil {
+		if level, ok := l.filter.Level(l.name); ok {
+			return level
+		}
+	}
+	return l.GetLevel()
 }
 
-// should returns true if the log event should be logged.
+// should returns true if the log event should be logged. Samplers implementing
+// EventSampler are preferred and run by logEvent once the event is built. The sampling
+// decision of the logger's trace, if any, replaces the sampler.
 func (l *Logger) should(lvl LogLevel) bool {
-	if lvl < l.level {
+	level := l.effectiveLevel()
+	if l.buffered && level.Severity() > DebugLevel.Severity() {
+		// the LogBuffer holds the events below the logger's level
+		level = DebugLevel
+	}
+	l.stats.consider(lvl)
+	if lvl.Severity() < level.Severity() {
+		l.stats.drop(lvl, DropLevel)
 		return false
 	}
-	if l.sampler != nil {
-		return l.sampler.Sample(lvl)
+	if l.trace != nil {
+		if l.trace.keep || lvl.Severity() >= l.trace.keepLevel.Severity() {
+			return true
+		}
+		l.stats.drop(lvl, DropSampler)
+		return false
+	}
+	if l.sampler != nil && l.eventSampler == nil && !l.sampler.Sample(lvl) {
+		l.stats.drop(lvl, DropSampler)
+		return false
 	}
 	return true
 }
@@ -253,12 +372,19 @@ func (l *Logger) should(lvl LogLevel) bool {
 // It does not create a new copy of the logger and rely on a mutex to enable thread safety,
 // so `With(Fields(fields...))` often is preferable.
 func (l *Logger) Append(fields ...Field) {
-	e := newEvent(l.writer, l.level)
+	e := newEvent(l.writer, l.level, l.encoder)
 	e.buf = nil
 	copyInternalLoggerFieldsToEvent(l, e)
+	e.withEntry = true
+	// the records and their data are kept by the logger
+	e.records, e.recordData = nil, nil
+	e.fieldStart = 0
 	for i := range fields {
 		fields[i](e)
 	}
+	if l.redactor != nil {
+		l.redactor.redactEvent(e, 0)
+	}
 	l.contextMutex.Lock()
 	if e.stack != l.stack {
 		l.stack = e.stack
@@ -270,7 +396,8 @@ func (l *Logger) Append(fields ...Field) {
 		l.timestamp = e.timestamp
 	}
 	if e.buf != nil {
-		l.context = enc.AppendObjectData(l.context, e.buf)
+		l.context, l.contextRecords = appendContextData(e.encoder, l.context, l.contextRecords, e.buf,
+			e.records)
 	}
 	l.contextMutex.Unlock()
 }
@@ -290,4 +417,7 @@ func copyInternalLoggerFieldsToEvent(l *Logger, e *Event) {
 	e.formatter = l.formatter
 	e.timestampFunc = l.timestampFunc
 	e.encoder = l.encoder
+	e.withEntry = l.formatter != nil || l.sampleEntry || len(l.hooks) > 0 || l.postHookEntry ||
+		l.redactor != nil || l.writerEntry
+	e.stats = l.stats
 }
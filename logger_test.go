@@ -7,8 +7,12 @@ import (
 	"net"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/skerkour/rz/cbor"
+	"github.com/skerkour/rz/logfmt"
 )
 
 func TestLog(t *testing.T) {
@@ -611,3 +615,184 @@ func TestWrite(t *testing.T) {
 		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
 	}
 }
+
+// upperEncoder is a custom encoder, embedding JSONEncoder.
+type upperEncoder struct {
+	JSONEncoder
+}
+
+func (enc upperEncoder) AppendString(dst []byte, s string) []byte {
+	return enc.JSONEncoder.AppendString(dst, strings.ToUpper(s))
+}
+
+func TestWithEncoder(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(Writer(out), WithEncoder(upperEncoder{}), Fields(Timestamp(false), String("ctx", "foo")))
+	log.Info("test", String("str", "bar"), Strings("strs", []string{"baz"}), Dict("dict", log.NewDict(String("in", "dict"))))
+	if got, want := decodeIfBinaryToString(out.Bytes()), `{"level":"INFO","ctx":"FOO","str":"BAR","strs":["baz"],"dict":{"in":"DICT"},"message":"TEST"}`+"\n"; got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+
+	out.Reset()
+	log = New(Writer(out), Fields(Timestamp(false)))
+	log.Info("test")
+	if got, want := decodeIfBinaryToString(out.Bytes()), `{"level":"info","message":"test"}`+"\n"; got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+// plainEncoder is a custom encoder without AppendEmbeddedJSON.
+type plainEncoder struct {
+	Encoder
+}
+
+func TestEmbeddedJSONFallback(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(Writer(out), WithEncoder(plainEncoder{JSONEncoder{}}), Fields(Timestamp(false)))
+	log.Info("", RawJSON("json", []byte(`{"some":"json"}`)))
+	if got, want := out.String(), `{"level":"info","json":"{\"some\":\"json\"}"}`+"\n"; got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestCBOREncoder(t *testing.T) {
+	logFields := []Field{
+		String("string", "foo"),
+		Bytes("bytes", []byte("bar")),
+		Hex("hex", []byte{0x12, 0xef}),
+		RawJSON("json", []byte(`{"some":"json"}`)),
+		Err(errors.New("some error")),
+		Errors("errors", []error{errors.New("some error"), nil}),
+		Ints("ints", []int{1, -2}),
+		Float64("float64", 12.30303),
+		Duration("dur", time.Second),
+		Time("time", time.Time{}),
+		IP("ip", net.IP{192, 168, 0, 100}),
+		Any("any", map[string]int{"a": 1}),
+		Map(map[string]interface{}{"uint8": uint8(7), "nil": nil}),
+	}
+
+	jsonOut := &bytes.Buffer{}
+	jsonLog := New(Writer(jsonOut), Fields(Timestamp(false), String("ctx", "foo")))
+	cborOut := &bytes.Buffer{}
+	cborLog := New(Writer(cborOut), WithEncoder(cbor.Encoder{}), Fields(Timestamp(false), String("ctx", "foo")))
+	for _, log := range []Logger{jsonLog, cborLog} {
+		log.Info("hello", append(logFields, Dict("dict", log.NewDict(Int("in", 1))))...)
+		log.Warn("world")
+	}
+
+	got, err := cbor.DecodeToJSON(cborOut.Bytes())
+	if err != nil {
+		t.Fatalf("cbor.DecodeToJSON() returned error: %s", err)
+	}
+	if want := jsonOut.String(); string(got) != want {
+		t.Errorf("invalid log output:\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(Writer(out), WithEncoder(logfmt.Encoder{}), Fields(Timestamp(false), String("ctx", "foo bar")))
+	log.Info("hello world",
+		Int("n", 123),
+		Strings("tags", []string{"a", "b"}),
+		Dict("user", log.NewDict(Int("id", 42), Dict("address", log.NewDict(String("city", "Paris"))))),
+		Err(errors.New("some error")),
+	)
+	log.Log("")
+	want := `level=info ctx="foo bar" n=123 tags.0=a tags.1=b user.id=42 user.address.city=Paris error="some error" message="hello world"` + "\n" +
+		`ctx="foo bar"` + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestEntryFormatter(t *testing.T) {
+	var entry Entry
+	log := New(Writer(&bytes.Buffer{}), Fields(Timestamp(false), String("ctx", "foo")),
+		Formatter(EntryFormatter(func(e *Entry) ([]byte, error) {
+			entry = *e
+			entry.Fields = append([]EntryField(nil), e.Fields...)
+			return nil, nil
+		})))
+	log.Info("hello",
+		Int("n", 123),
+		Dict("user", log.NewDict(Int64("id", 42))),
+		Strings("tags", []string{"a", "b"}),
+	)
+	want := Entry{
+		Level:   InfoLevel,
+		Message: "hello",
+		Fields: []EntryField{
+			{"ctx", "foo"},
+			{"n", 123},
+			{"user", []EntryField{{"id", int64(42)}}},
+			{"tags", []string{"a", "b"}},
+		},
+		levelFieldName:     DefaultLevelFieldName,
+		messageFieldName:   DefaultMessageFieldName,
+		timestampFieldName: DefaultTimestampFieldName,
+		callerFieldName:    DefaultCallerFieldName,
+		timeFieldFormat:    DefaultTimeFieldFormat,
+	}
+	if !reflect.DeepEqual(entry, want) {
+		t.Errorf("invalid entry:\ngot:  %#v\nwant: %#v", entry, want)
+	}
+}
+
+func TestFormatterLogfmt(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(Writer(out), Formatter(FormatterLogfmt()), Fields(Timestamp(false), String("ctx", "foo bar")))
+	log.Info("hello world",
+		Int("n", 123),
+		Float64("f", 1.5),
+		Dict("user", log.NewDict(Int("id", 42), Dict("address", log.NewDict(String("city", "Paris"))))),
+		Strings("tags", []string{"a", "b"}),
+		Err(errors.New("some error")),
+	)
+	want := `level=info message="hello world" ctx="foo bar" n=123 f=1.5 user.id=42 user.address.city=Paris tags="[\"a\",\"b\"]" error="some error"` + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestFormatterConsole(t *testing.T) {
+	out := &bytes.Buffer{}
+	now := time.Date(2001, time.February, 3, 4, 5, 6, 0, time.UTC)
+	log := New(Writer(out), Formatter(FormatterConsole()), TimeFieldFormat("2006-01-02 15:04"),
+		TimestampFunc(func() time.Time { return now }))
+	log.Info("hello",
+		Int("n", 123),
+		Dict("user", log.NewDict(Int("id", 42), Dict("address", log.NewDict(String("city", "Paris"))))),
+		Time("at", now),
+	)
+	want := fmt.Sprintf("%-20s |%-4s| hello %s=123 %s={\"address\":{\"city\":\"Paris\"},\"id\":42} %s=\"2001-02-03 04:05\"\n",
+		"2001-02-03 04:05", colorize("INFO", cCyan), colorize("n", cCyan), colorize("user", cCyan), colorize("at", cCyan))
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestLevelRef(t *testing.T) {
+	out := &bytes.Buffer{}
+	level := NewAtomicLevel(InfoLevel)
+	log := New(Writer(out), LevelRef(level), Fields(Timestamp(false)))
+	child := log.With(Fields(String("child", "true")))
+
+	log.Debug("hidden")
+	child.Debug("hidden")
+	level.SetLevel(DebugLevel)
+	log.Debug("visible")
+	child.Debug("visible")
+	detached := child.With(Level(ErrorLevel))
+	detached.Warn("hidden")
+
+	want := `{"level":"debug","message":"visible"}` + "\n" +
+		`{"level":"debug","child":"true","message":"visible"}` + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+	if got := child.GetLevel(); got != DebugLevel {
+		t.Errorf("GetLevel() = %v, want %v", got, DebugLevel)
+	}
+}
@@ -1,8 +1,21 @@
 package rz
 
-import "fmt"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
 
 // LogLevel defines log levels.
+//
+// Levels are ordered by their severity, not by their value: TraceLevel and the custom
+// levels registered with RegisterLevel take values above Disabled, so the other levels
+// keep the values of the previous versions. Use Severity to compare levels.
 type LogLevel uint8
 
 const (
@@ -22,10 +35,35 @@ const (
 	NoLevel
 	// Disabled disables the logger.
 	Disabled
+	// TraceLevel defines trace log level, less severe than DebugLevel.
+	TraceLevel
 )
 
+// levelSeverities are the severities of the levels, by value. The entries of the custom
+// levels are written by RegisterLevel, with customLevelsMutex held.
+var levelSeverities = [256]uint32{
+	TraceLevel: 10,
+	DebugLevel: 20,
+	InfoLevel:  30,
+	WarnLevel:  40,
+	ErrorLevel: 50,
+	FatalLevel: 60,
+	PanicLevel: 70,
+	NoLevel:    254,
+	Disabled:   255,
+}
+
+// Severity returns the severity of the level, which orders levels: the Bunyan and pino
+// level numbers for the predefined levels, from 10 for trace to 60 for fatal and 70 for
+// panic, 254 for NoLevel and 255 for Disabled. Unknown levels have a severity of 0.
+func (l LogLevel) Severity() uint8 {
+	return uint8(atomic.LoadUint32(&levelSeverities[l]))
+}
+
 func (l LogLevel) String() string {
 	switch l {
+	case TraceLevel:
+		return "trace"
 	case DebugLevel:
 		return "debug"
 	case InfoLevel:
@@ -41,27 +79,337 @@ func (l LogLevel) String() string {
 	case NoLevel:
 		return ""
 	}
+	if custom, ok := lookupCustomLevel(l); ok {
+		return custom.Name
+	}
 	return ""
 }
 
+// upperString returns the upper-cased name of the level, without allocating.
+func (l LogLevel) upperString() string {
+	switch l {
+	case TraceLevel:
+		return "TRACE"
+	case DebugLevel:
+		return "DEBUG"
+	case InfoLevel:
+		return "INFO"
+	case WarnLevel:
+		return "WARNING"
+	case ErrorLevel:
+		return "ERROR"
+	case FatalLevel:
+		return "FATAL"
+	case PanicLevel:
+		return "PANIC"
+	case NoLevel:
+		return ""
+	}
+	return customLevels.Load().(*customLevelRegistry).upperNames[l]
+}
+
 // ParseLevel converts a level string into a rz Level value.
 // returns an error if the input string does not match known values.
+//
+// The level names are case insensitive and common aliases are accepted: "warn", "err",
+// "crit", "off"... The values of the known levels are accepted too, e.g. "4" for fatal.
+// Use LevelFormat.ParseLevel to parse the numbers of the syslog or Bunyan formats.
 func ParseLevel(levelStr string) (LogLevel, error) {
-	switch levelStr {
-	case DebugLevel.String():
-		return DebugLevel, nil
-	case InfoLevel.String():
-		return InfoLevel, nil
-	case WarnLevel.String():
-		return WarnLevel, nil
-	case ErrorLevel.String():
-		return ErrorLevel, nil
-	case FatalLevel.String():
-		return FatalLevel, nil
-	case PanicLevel.String():
-		return PanicLevel, nil
-	case NoLevel.String():
-		return NoLevel, nil
+	return LevelFormatString.ParseLevel(levelStr)
+}
+
+// ParseLevel converts a level string, as encoded with the format, into a rz Level value.
+// returns an error if the input string does not match known values.
+//
+// Level names are accepted whatever the format, like by the package's ParseLevel, while
+// numbers are read according to the format:
+//   - LevelFormatSyslog: syslog severities, e.g. "4" for warn. Notice is read as info, and
+//     emergency as panic.
+//   - LevelFormatBunyan: Bunyan and pino numbers, see LogLevel.Severity, e.g. "40" for warn.
+//   - LevelFormatString and LevelFormatUpper: level values, e.g. "2" for warn.
+func (f LevelFormat) ParseLevel(levelStr string) (LogLevel, error) {
+	name := strings.ToLower(strings.TrimSpace(levelStr))
+	if level, ok := parseLevelName(name); ok {
+		return level, nil
+	}
+	if n, err := strconv.ParseUint(name, 10, 8); err == nil {
+		if level, ok := f.levelOf(uint8(n)); ok {
+			return level, nil
+		}
 	}
 	return NoLevel, fmt.Errorf("Unknown Level String: '%s', defaulting to NoLevel", levelStr)
 }
+
+func parseLevelName(name string) (LogLevel, bool) {
+	switch name {
+	case "trace", "trc":
+		return TraceLevel, true
+	case "debug", "dbg":
+		return DebugLevel, true
+	case "info", "information", "inf":
+		return InfoLevel, true
+	case "warning", "warn", "wrn":
+		return WarnLevel, true
+	case "error", "err":
+		return ErrorLevel, true
+	case "fatal", "critical", "crit", "ftl":
+		return FatalLevel, true
+	case "panic":
+		return PanicLevel, true
+	case "":
+		return NoLevel, true
+	case "disabled", "off", "none":
+		return Disabled, true
+	}
+	level, ok := customLevels.Load().(*customLevelRegistry).byName[name]
+	return level, ok
+}
+
+// syslogLevels are the levels of the syslog severities, from emergency to debug.
+var syslogLevels = [...]LogLevel{PanicLevel, PanicLevel, FatalLevel, ErrorLevel, WarnLevel, InfoLevel, InfoLevel, DebugLevel}
+
+// levelOf returns the level encoded as n with the format.
+func (f LevelFormat) levelOf(n uint8) (LogLevel, bool) {
+	switch f {
+	case LevelFormatSyslog:
+		if int(n) < len(syslogLevels) {
+			return syslogLevels[n], true
+		}
+	case LevelFormatBunyan:
+		for value := range levelSeverities {
+			if level := LogLevel(value); n != 0 && level.Severity() == n {
+				return level, true
+			}
+		}
+	default:
+		if level := LogLevel(n); level.Severity() != 0 {
+			return level, true
+		}
+	}
+	return NoLevel, false
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. The level is encoded as
+// its name, except Disabled, which has no name and is encoded as "disabled".
+func (l LogLevel) MarshalText() ([]byte, error) {
+	if l == Disabled {
+		return []byte("disabled"), nil
+	}
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, using ParseLevel.
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The level is encoded as in
+// MarshalText.
+func (l LogLevel) MarshalJSON() ([]byte, error) {
+	text, _ := l.MarshalText()
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The level can be encoded
+// either as a string or as a number, as accepted by ParseLevel.
+func (l *LogLevel) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var levelStr string
+		if err := json.Unmarshal(data, &levelStr); err != nil {
+			return err
+		}
+		data = []byte(levelStr)
+	}
+	return l.UnmarshalText(data)
+}
+
+// Set implements the flag.Value interface, using ParseLevel.
+func (l *LogLevel) Set(levelStr string) error {
+	return l.UnmarshalText([]byte(levelStr))
+}
+
+// LevelFormat defines how the level field of the events is encoded.
+type LevelFormat uint8
+
+const (
+	// LevelFormatString encodes the level as its name, e.g. "warning". It's the default.
+	LevelFormatString LevelFormat = iota
+	// LevelFormatUpper encodes the level as its upper-cased name, e.g. "WARNING".
+	LevelFormatUpper
+	// LevelFormatSyslog encodes the level as its syslog severity number, e.g. 4 for warn.
+	LevelFormatSyslog
+	// LevelFormatBunyan encodes the level as its Bunyan and pino number, which is its
+	// severity, e.g. 40 for warn. The panic level, unknown to Bunyan and pino, is encoded
+	// as 70.
+	LevelFormatBunyan
+)
+
+// appendLevel appends level's field to the event, in the given format.
+func (e *Event) appendLevel(level LogLevel, format LevelFormat) {
+	e.buf = e.encoder.AppendKey(e.buf, e.levelFieldName)
+	switch format {
+	case LevelFormatUpper:
+		e.buf = e.encoder.AppendString(e.buf, level.upperString())
+	case LevelFormatSyslog:
+		e.buf = e.encoder.AppendInt(e.buf, syslogSeverity(level))
+	case LevelFormatBunyan:
+		e.buf = e.encoder.AppendInt(e.buf, int(level.Severity()))
+	default:
+		e.buf = e.encoder.AppendString(e.buf, level.String())
+	}
+}
+
+// CustomLevel describes a level registered with RegisterLevel.
+type CustomLevel struct {
+	// Name is the name of the level, as logged in the level field and parsed, case
+	// insensitively, by ParseLevel.
+	Name string
+	// Severity orders the level among the others, see LogLevel.Severity. Events are logged
+	// if their severity is greater or equal to the one of the logger's level, e.g. a level
+	// of severity 35 is logged by loggers with InfoLevel (30) but not by loggers with
+	// WarnLevel (40). It's also the level's number with LevelFormatBunyan.
+	Severity uint8
+	// Color is the ANSI color code of the level in the console and CLI formatters,
+	// e.g. 32 for green. Zero leaves the level uncolored.
+	Color int
+	// Symbol is the prefix of the level's events in the CLI formatter. It defaults to "• ".
+	Symbol string
+}
+
+type customLevelRegistry struct {
+	byLevel    map[LogLevel]CustomLevel
+	byName     map[string]LogLevel
+	upperNames map[LogLevel]string
+}
+
+var (
+	customLevels      atomic.Value // *customLevelRegistry
+	customLevelsMutex sync.Mutex
+	errInvalidLevel   = errors.New("rz: custom level severity must be between 1 and 253")
+	errEmptyLevelName = errors.New("rz: custom level name must not be empty")
+	errTooManyLevels  = errors.New("rz: too many custom levels")
+)
+
+func init() {
+	customLevels.Store(&customLevelRegistry{
+		byLevel:    map[LogLevel]CustomLevel{},
+		byName:     map[string]LogLevel{},
+		upperNames: map[LogLevel]string{},
+	})
+}
+
+// RegisterLevel registers a custom level, and returns the LogLevel to log its events with,
+// e.g. with Logger.LogWithLevel. Its name and severity must not be used by a predefined
+// or an already registered level.
+// Levels should be registered during the program's initialization, before logging.
+func RegisterLevel(level CustomLevel) (LogLevel, error) {
+	if level.Name == "" {
+		return NoLevel, errEmptyLevelName
+	}
+	if level.Severity == 0 || level.Severity >= NoLevel.Severity() {
+		return NoLevel, errInvalidLevel
+	}
+
+	customLevelsMutex.Lock()
+	defer customLevelsMutex.Unlock()
+
+	free := -1
+	for value := len(levelSeverities) - 1; value >= 0; value-- {
+		switch LogLevel(value).Severity() {
+		case level.Severity:
+			return NoLevel, fmt.Errorf("rz: level severity %d is already used by %q", level.Severity,
+				LogLevel(value).String())
+		case 0:
+			free = value
+		}
+	}
+	if free < 0 {
+		return NoLevel, errTooManyLevels
+	}
+	if _, err := ParseLevel(level.Name); err == nil {
+		return NoLevel, fmt.Errorf("rz: level name %q is already used", level.Name)
+	}
+
+	value := LogLevel(free)
+	updateCustomLevels(func(registry *customLevelRegistry) {
+		registry.byLevel[value] = level
+		registry.byName[strings.ToLower(level.Name)] = value
+		registry.upperNames[value] = strings.ToUpper(level.Name)
+	})
+	atomic.StoreUint32(&levelSeverities[value], uint32(level.Severity))
+	return value, nil
+}
+
+// unregisterLevel removes a custom level from the registry, e.g. once a test is done.
+func unregisterLevel(level LogLevel) {
+	customLevelsMutex.Lock()
+	defer customLevelsMutex.Unlock()
+
+	custom, ok := lookupCustomLevel(level)
+	if !ok {
+		return
+	}
+	atomic.StoreUint32(&levelSeverities[level], 0)
+	updateCustomLevels(func(registry *customLevelRegistry) {
+		delete(registry.byLevel, level)
+		delete(registry.byName, strings.ToLower(custom.Name))
+		delete(registry.upperNames, level)
+	})
+}
+
+// updateCustomLevels stores a copy of the registry updated by update.
+// customLevelsMutex must be held.
+func updateCustomLevels(update func(registry *customLevelRegistry)) {
+	old := customLevels.Load().(*customLevelRegistry)
+	registry := &customLevelRegistry{
+		byLevel:    make(map[LogLevel]CustomLevel, len(old.byLevel)+1),
+		byName:     make(map[string]LogLevel, len(old.byName)+1),
+		upperNames: make(map[LogLevel]string, len(old.upperNames)+1),
+	}
+	for value, custom := range old.byLevel {
+		registry.byLevel[value] = custom
+		registry.byName[strings.ToLower(custom.Name)] = value
+		registry.upperNames[value] = strings.ToUpper(custom.Name)
+	}
+	update(registry)
+	customLevels.Store(registry)
+}
+
+func lookupCustomLevel(level LogLevel) (CustomLevel, bool) {
+	custom, ok := customLevels.Load().(*customLevelRegistry).byLevel[level]
+	return custom, ok
+}
+
+// AtomicLevel is a LogLevel which can be changed at runtime, safely from any goroutine.
+// Loggers sharing an AtomicLevel with the LevelRef option all follow its changes.
+type AtomicLevel struct {
+	level uint32
+}
+
+// NewAtomicLevel creates an AtomicLevel set to level.
+func NewAtomicLevel(level LogLevel) *AtomicLevel {
+	return &AtomicLevel{level: uint32(level)}
+}
+
+// Level returns the current level.
+func (a *AtomicLevel) Level() LogLevel {
+	return LogLevel(atomic.LoadUint32(&a.level))
+}
+
+// SetLevel updates the level.
+func (a *AtomicLevel) SetLevel(level LogLevel) {
+	atomic.StoreUint32(&a.level, uint32(level))
+}
+
+func (a *AtomicLevel) String() string {
+	return a.Level().String()
+}
@@ -0,0 +1,235 @@
+package rz
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"testing"
+)
+
+func TestTraceLevel(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(Writer(out), Fields(Timestamp(false)))
+	log.Trace("hidden")
+	if got := out.String(); got != "" {
+		t.Errorf("trace event logged with debug level: %q", got)
+	}
+
+	log = log.With(Level(TraceLevel))
+	log.Trace("trace")
+	if got, want := out.String(), `{"level":"trace","message":"trace"}`+"\n"; got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+	if level, err := ParseLevel("trace"); err != nil || level != TraceLevel {
+		t.Errorf("ParseLevel(\"trace\") = %v, %v, want %v", level, err, TraceLevel)
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	noticeLevel, err := RegisterLevel(CustomLevel{Name: "notice", Severity: 35, Color: cGreen, Symbol: "➜ "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { unregisterLevel(noticeLevel) })
+
+	invalid := []CustomLevel{
+		{Name: "", Severity: 36},
+		{Name: "notice", Severity: 36},
+		{Name: "Info", Severity: 36},
+		{Name: "info2", Severity: 30},
+		{Name: "info3", Severity: 35},
+		{Name: "info4", Severity: 0},
+		{Name: "info5", Severity: 254},
+	}
+	for _, level := range invalid {
+		if _, err := RegisterLevel(level); err == nil {
+			t.Errorf("RegisterLevel(%+v) should fail", level)
+		}
+	}
+
+	if noticeLevel <= TraceLevel || noticeLevel.Severity() != 35 {
+		t.Errorf("RegisterLevel = %d of severity %d", noticeLevel, noticeLevel.Severity())
+	}
+	if got := noticeLevel.String(); got != "notice" {
+		t.Errorf("String() = %q, want \"notice\"", got)
+	}
+	if level, err := ParseLevel("notice"); err != nil || level != noticeLevel {
+		t.Errorf("ParseLevel(\"notice\") = %v, %v, want %v", level, err, noticeLevel)
+	}
+
+	out := &bytes.Buffer{}
+	var hooked, sampled bool
+	hook := LevelHook{CustomHooks: map[LogLevel]LogHook{
+		noticeLevel: HookFunc(func(e *Event, level LogLevel, message string) { hooked = true }),
+	}}
+	sampler := SamplerLevel{CustomSamplers: map[LogLevel]LogSampler{
+		noticeLevel: samplerFunc(func(lvl LogLevel) bool { sampled = true; return true }),
+	}}
+	log := New(Writer(out), Level(noticeLevel), Fields(Timestamp(false)), Hooks(hook), Sampler(sampler))
+	log.Info("hidden")
+	log.LogWithLevel(noticeLevel, "audit")
+	if got, want := out.String(), `{"level":"notice","message":"audit"}`+"\n"; got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+	if !hooked || !sampled {
+		t.Errorf("custom level hook run: %v, sampler run: %v", hooked, sampled)
+	}
+
+	out.Reset()
+	log = New(Writer(out), Formatter(FormatterCLI()))
+	log.LogWithLevel(noticeLevel, "audit")
+	if got, want := out.String(), colorize("➜ ", cGreen)+"audit\n"; got != want {
+		t.Errorf("invalid CLI output:\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+type samplerFunc func(lvl LogLevel) bool
+
+func (f samplerFunc) Sample(lvl LogLevel) bool {
+	return f(lvl)
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want LogLevel
+	}{
+		{"trace", TraceLevel},
+		{"DEBUG", DebugLevel},
+		{" Info ", InfoLevel},
+		{"warn", WarnLevel},
+		{"WARNING", WarnLevel},
+		{"err", ErrorLevel},
+		{"crit", FatalLevel},
+		{"panic", PanicLevel},
+		{"", NoLevel},
+		{"off", Disabled},
+		{"disabled", Disabled},
+		{"4", FatalLevel},
+		{"0", DebugLevel},
+		{"8", TraceLevel},
+		{"7", Disabled},
+	}
+	for _, tt := range tests {
+		if got, err := ParseLevel(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"verbose", "9", "40", "-1", "256"} {
+		if _, err := ParseLevel(in); err == nil {
+			t.Errorf("ParseLevel(%q) should fail", in)
+		}
+	}
+}
+
+func TestLevelMarshaling(t *testing.T) {
+	if s := Disabled.String(); s != "" {
+		t.Errorf("Disabled.String() = %q, want \"\"", s)
+	}
+	if text, _ := Disabled.MarshalText(); string(text) != "disabled" {
+		t.Errorf("Disabled.MarshalText() = %q, want \"disabled\"", text)
+	}
+	for _, level := range []LogLevel{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel, NoLevel, Disabled} {
+		text, err := level.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got LogLevel
+		if err = got.UnmarshalText(text); err != nil || got != level {
+			t.Errorf("text round-trip of %v = %v, %v", level, got, err)
+		}
+
+		data, err := json.Marshal(level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = 0
+		if err = json.Unmarshal(data, &got); err != nil || got != level {
+			t.Errorf("JSON round-trip of %v (%s) = %v, %v", level, data, got, err)
+		}
+	}
+
+	var config struct {
+		Level LogLevel `json:"level"`
+		Other LogLevel `json:"other"`
+	}
+	if err := json.Unmarshal([]byte(`{"level":1,"other":"WARN"}`), &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Level != InfoLevel || config.Other != WarnLevel {
+		t.Errorf("json.Unmarshal = %+v", config)
+	}
+	if err := json.Unmarshal([]byte(`{"level":"verbose"}`), &config); err == nil {
+		t.Error("json.Unmarshal of an unknown level should fail")
+	}
+
+	level := InfoLevel
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Var(&level, "level", "log level")
+	if err := flags.Parse([]string{"-level", "err"}); err != nil {
+		t.Fatal(err)
+	}
+	if level != ErrorLevel {
+		t.Errorf("flag value = %v, want %v", level, ErrorLevel)
+	}
+}
+
+func TestLevelFieldFormat(t *testing.T) {
+	tests := []struct {
+		format LevelFormat
+		want   string
+	}{
+		{LevelFormatString, `{"level":"warning","message":"msg"}` + "\n"},
+		{LevelFormatUpper, `{"level":"WARNING","message":"msg"}` + "\n"},
+		{LevelFormatSyslog, `{"level":4,"message":"msg"}` + "\n"},
+		{LevelFormatBunyan, `{"level":40,"message":"msg"}` + "\n"},
+	}
+	for _, tt := range tests {
+		out := &bytes.Buffer{}
+		log := New(Writer(out), Fields(Timestamp(false)), LevelFieldFormat(tt.format))
+		log.Warn("msg")
+		if got := decodeIfBinaryToString(out.Bytes()); got != tt.want {
+			t.Errorf("invalid log output for format %d:\ngot:  %v\nwant: %v", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestLevelFormatParseLevel(t *testing.T) {
+	tests := []struct {
+		format LevelFormat
+		in     string
+		want   LogLevel
+	}{
+		{LevelFormatString, "2", WarnLevel},
+		{LevelFormatUpper, "WARNING", WarnLevel},
+		{LevelFormatSyslog, "4", WarnLevel},
+		{LevelFormatSyslog, "7", DebugLevel},
+		{LevelFormatSyslog, "5", InfoLevel},
+		{LevelFormatSyslog, "1", PanicLevel},
+		{LevelFormatSyslog, "warn", WarnLevel},
+		{LevelFormatBunyan, "40", WarnLevel},
+		{LevelFormatBunyan, "10", TraceLevel},
+		{LevelFormatBunyan, "70", PanicLevel},
+		{LevelFormatBunyan, "debug", DebugLevel},
+	}
+	for _, tt := range tests {
+		if got, err := tt.format.ParseLevel(tt.in); err != nil || got != tt.want {
+			t.Errorf("format %d: ParseLevel(%q) = %v, %v, want %v", tt.format, tt.in, got, err, tt.want)
+		}
+	}
+
+	invalid := []struct {
+		format LevelFormat
+		in     string
+	}{
+		{LevelFormatSyslog, "8"},
+		{LevelFormatBunyan, "4"},
+		{LevelFormatBunyan, "0"},
+		{LevelFormatString, "40"},
+	}
+	for _, tt := range invalid {
+		if _, err := tt.format.ParseLevel(tt.in); err == nil {
+			t.Errorf("format %d: ParseLevel(%q) should fail", tt.format, tt.in)
+		}
+	}
+}
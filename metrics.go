@@ -0,0 +1,260 @@
+package rz
+
+import (
+	"bufio"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultMetricsMaxFieldValues is the default number of distinct field values counted
+	// by Metrics.
+	DefaultMetricsMaxFieldValues = 100
+	// MetricsOtherFieldValue is the field value under which Metrics counts the events once
+	// the maximum number of distinct field values is reached.
+	MetricsOtherFieldValue = "other"
+)
+
+// MetricsOption is used to configure Metrics.
+type MetricsOption func(m *Metrics)
+
+// MetricsField update the field by which events are counted, in addition to the level,
+// e.g. DefaultComponentFieldName. Its values must have a low cardinality: past the
+// maximum number of values, events are counted under MetricsOtherFieldValue.
+func MetricsField(fieldName string) MetricsOption {
+	return func(m *Metrics) {
+		m.field = fieldName
+	}
+}
+
+// MetricsMaxFieldValues update the maximum number of distinct field values counted. It
+// defaults to DefaultMetricsMaxFieldValues.
+func MetricsMaxFieldValues(maxValues int) MetricsOption {
+	return func(m *Metrics) {
+		m.maxValues = maxValues
+	}
+}
+
+// Metrics is a post hook counting the events written by loggers, and their bytes, by level
+// and optionally by the value of a field. Add it to loggers with AddPostHook.
+//
+// Metrics implements expvar.Var, and can be exposed in the Prometheus text format with
+// WritePrometheus, e.g. by rzhttp.MetricsHandler. A Metrics is safe for concurrent use.
+type Metrics struct {
+	total     metricsCounters
+	field     string
+	maxValues int
+
+	mu     sync.RWMutex
+	values map[string]*metricsCounters
+}
+
+type metricsCounters struct {
+	levels [256]levelMetrics
+}
+
+type levelMetrics struct {
+	events uint64
+	bytes  uint64
+}
+
+// NewMetrics creates a Metrics.
+func NewMetrics(options ...MetricsOption) *Metrics {
+	m := &Metrics{
+		maxValues: DefaultMetricsMaxFieldValues,
+		values:    map[string]*metricsCounters{},
+	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// RunPost implements the LogPostHook interface. Events are counted whether their write
+// succeeded or not.
+func (m *Metrics) RunPost(level LogLevel, p []byte, err error) {
+	m.total.add(level, len(p))
+}
+
+func (m *Metrics) runPostEvent(e *Event, err error) {
+	m.total.add(e.level, len(e.buf))
+	if m.field != "" {
+		m.fieldCounters(e).add(e.level, len(e.buf))
+	}
+}
+
+func (m *Metrics) usesEntry() bool {
+	return m.field != ""
+}
+
+// fieldCounters returns the counters of the value of the event's field.
+func (m *Metrics) fieldCounters(e *Event) *metricsCounters {
+	var value string
+	if i := e.lookupEntryField(m.field); i >= 0 {
+		if e.records[i].kind == kindString {
+			value = e.records[i].str
+		} else {
+			value = fmt.Sprint(entryValue(e.records[i:]))
+		}
+	}
+
+	m.mu.RLock()
+	counters, ok := m.values[value]
+	m.mu.RUnlock()
+	if ok {
+		return counters
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if counters, ok = m.values[value]; ok {
+		return counters
+	}
+	if len(m.values) >= m.maxValues {
+		value = MetricsOtherFieldValue
+		if counters, ok = m.values[value]; ok {
+			return counters
+		}
+	}
+	counters = &metricsCounters{}
+	m.values[value] = counters
+	return counters
+}
+
+func (c *metricsCounters) add(level LogLevel, n int) {
+	atomic.AddUint64(&c.levels[level].events, 1)
+	atomic.AddUint64(&c.levels[level].bytes, uint64(n))
+}
+
+// Events returns the number of events of level written.
+func (m *Metrics) Events(level LogLevel) uint64 {
+	return atomic.LoadUint64(&m.total.levels[level].events)
+}
+
+// Bytes returns the number of bytes of the events of level written.
+func (m *Metrics) Bytes(level LogLevel) uint64 {
+	return atomic.LoadUint64(&m.total.levels[level].bytes)
+}
+
+// String implements the expvar.Var interface. The counts are encoded in JSON by level
+// name, with the counts by field value under "by_" followed by the field name:
+//
+//	{"events":{"info":3},"bytes":{"info":150},"by_component":{"db":{"events":{"info":1},"bytes":{"info":50}}}}
+func (m *Metrics) String() string {
+	vars := m.total.vars()
+	if m.field != "" {
+		byValue := map[string]interface{}{}
+		m.mu.RLock()
+		for value, counters := range m.values {
+			byValue[value] = counters.vars()
+		}
+		m.mu.RUnlock()
+		vars["by_"+m.field] = byValue
+	}
+	b, err := json.Marshal(vars)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// Publish publishes the metrics as the expvar variable name. Like expvar.Publish, it
+// panics if name is already used.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, m)
+}
+
+func (c *metricsCounters) vars() map[string]interface{} {
+	events := map[string]uint64{}
+	bytes := map[string]uint64{}
+	for i := range c.levels {
+		level := LogLevel(i)
+		if n := atomic.LoadUint64(&c.levels[i].events); n > 0 {
+			events[statsLevelName(level)] = n
+			bytes[statsLevelName(level)] = atomic.LoadUint64(&c.levels[i].bytes)
+		}
+	}
+	return map[string]interface{}{"events": events, "bytes": bytes}
+}
+
+// WritePrometheus writes the metrics to w in the Prometheus text exposition format, as
+// the counters rz_log_events_total and rz_log_bytes_total, labeled by level and field.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	type series struct {
+		value    string
+		counters *metricsCounters
+	}
+	var all []series
+	label := ""
+	if m.field == "" {
+		all = []series{{counters: &m.total}}
+	} else {
+		label = promLabelName(m.field)
+		m.mu.RLock()
+		for value, counters := range m.values {
+			all = append(all, series{value, counters})
+		}
+		m.mu.RUnlock()
+		sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+	}
+
+	bw := bufio.NewWriter(w)
+	metrics := []struct {
+		name, help string
+		bytes      bool
+	}{
+		{"rz_log_events_total", "Number of log events written.", false},
+		{"rz_log_bytes_total", "Number of bytes of log events written.", true},
+	}
+	for _, metric := range metrics {
+		bw.WriteString("# HELP " + metric.name + " " + metric.help + "\n")
+		bw.WriteString("# TYPE " + metric.name + " counter\n")
+		for i := range m.total.levels {
+			if atomic.LoadUint64(&m.total.levels[i].events) == 0 {
+				continue
+			}
+			for _, s := range all {
+				counter := &s.counters.levels[i].events
+				if metric.bytes {
+					counter = &s.counters.levels[i].bytes
+				}
+				n := atomic.LoadUint64(counter)
+				if n == 0 && label != "" {
+					continue
+				}
+				bw.WriteString(metric.name + `{level="` + promLabelValue(statsLevelName(LogLevel(i))) + `"`)
+				if label != "" {
+					bw.WriteString("," + label + `="` + promLabelValue(s.value) + `"`)
+				}
+				bw.WriteString("} " + strconv.FormatUint(n, 10) + "\n")
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// promLabelName returns name with the characters invalid in Prometheus label names
+// replaced by underscores.
+func promLabelName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+var promLabelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabelValue escapes value for the Prometheus text format.
+func promLabelValue(value string) string {
+	return promLabelValueReplacer.Replace(value)
+}
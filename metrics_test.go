@@ -0,0 +1,90 @@
+package rz
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	out := &bytes.Buffer{}
+	log := New(Writer(out), Level(InfoLevel), Fields(Timestamp(false)), AddPostHook(metrics))
+	log.Info("hello")
+	log.Info("world")
+	log.Error("failed")
+	log.Debug("dropped")
+
+	if got := metrics.Events(InfoLevel); got != 2 {
+		t.Errorf("Events(info) = %d, want 2", got)
+	}
+	if got := metrics.Events(DebugLevel); got != 0 {
+		t.Errorf("Events(debug) = %d, want 0", got)
+	}
+	if got, want := metrics.Bytes(InfoLevel)+metrics.Bytes(ErrorLevel), uint64(out.Len()); got != want {
+		t.Errorf("Bytes() = %d, want %d", got, want)
+	}
+
+	prom := &bytes.Buffer{}
+	if err := metrics.WritePrometheus(prom); err != nil {
+		t.Fatal(err)
+	}
+	want := "# HELP rz_log_events_total Number of log events written.\n" +
+		"# TYPE rz_log_events_total counter\n" +
+		`rz_log_events_total{level="info"} 2` + "\n" +
+		`rz_log_events_total{level="error"} 1` + "\n" +
+		"# HELP rz_log_bytes_total Number of bytes of log events written.\n" +
+		"# TYPE rz_log_bytes_total counter\n" +
+		`rz_log_bytes_total{level="info"} 70` + "\n" +
+		`rz_log_bytes_total{level="error"} 37` + "\n"
+	if got := prom.String(); got != want {
+		t.Errorf("invalid Prometheus output:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestMetricsField(t *testing.T) {
+	metrics := NewMetrics(MetricsField(DefaultComponentFieldName), MetricsMaxFieldValues(2))
+	log := New(Writer(&bytes.Buffer{}), Fields(Timestamp(false)), AddPostHook(metrics))
+	log.Info("no component")
+	db, httpLog, cache := log.Named("db"), log.Named("http"), log.Named("cache")
+	db.Info("query")
+	db.Warn("slow query")
+	httpLog.Info("request")
+	cache.Info("miss")
+
+	prom := &bytes.Buffer{}
+	if err := metrics.WritePrometheus(prom); err != nil {
+		t.Fatal(err)
+	}
+	wantEvents := "# HELP rz_log_events_total Number of log events written.\n" +
+		"# TYPE rz_log_events_total counter\n" +
+		`rz_log_events_total{level="info",component=""} 1` + "\n" +
+		`rz_log_events_total{level="info",component="db"} 1` + "\n" +
+		`rz_log_events_total{level="info",component="other"} 2` + "\n" +
+		`rz_log_events_total{level="warning",component="db"} 1` + "\n"
+	if got := prom.String(); !bytes.HasPrefix([]byte(got), []byte(wantEvents)) {
+		t.Errorf("invalid Prometheus output:\ngot:  %v\nwant: %v...", got, wantEvents)
+	}
+
+	var vars struct {
+		Events      map[string]uint64
+		ByComponent map[string]struct {
+			Events map[string]uint64
+		} `json:"by_component"`
+	}
+	if err := json.Unmarshal([]byte(metrics.String()), &vars); err != nil {
+		t.Fatalf("String() is not valid JSON: %v", err)
+	}
+	if vars.Events["info"] != 4 || vars.ByComponent["db"].Events["warning"] != 1 {
+		t.Errorf("invalid expvar output: %s", metrics.String())
+	}
+}
+
+func TestPromLabel(t *testing.T) {
+	if got, want := promLabelName("http.status-code"), "http_status_code"; got != want {
+		t.Errorf("promLabelName() = %s, want %s", got, want)
+	}
+	if got, want := promLabelValue("a\"b\\c\nd"), `a\"b\\c\nd`; got != want {
+		t.Errorf("promLabelValue() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,406 @@
+package rz
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultRedactReplacement is the default replacement of redacted values.
+const DefaultRedactReplacement = "[REDACTED]"
+
+var (
+	// JWTPattern matches JSON Web Tokens, e.g. in "Bearer eyJhbGciOi...".
+	JWTPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+	// CreditCardPattern matches credit card numbers of 13 to 19 digits, optionally
+	// separated by spaces or dashes. Redactors only redact its matches passing the Luhn
+	// check, so other numbers, e.g. identifiers or timestamps, are kept.
+	CreditCardPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+)
+
+// RedactorOption is used to configure a Redactor.
+type RedactorOption func(r *Redactor)
+
+// RedactKeys update the keys of the fields whose value is redacted. Keys are matched case
+// insensitively, either exactly or, if they contain *, ? or [, as glob patterns, e.g.
+// "*password*".
+func RedactKeys(keys ...string) RedactorOption {
+	return func(r *Redactor) {
+		for _, key := range keys {
+			key = strings.ToLower(key)
+			if strings.ContainsAny(key, "*?[") {
+				r.globs = append(r.globs, key)
+			} else {
+				r.keys[key] = struct{}{}
+			}
+		}
+	}
+}
+
+// RedactValues update the patterns redacted in string values, e.g. JWTPattern. Only the
+// matched parts of the values are redacted.
+func RedactValues(patterns ...*regexp.Regexp) RedactorOption {
+	return func(r *Redactor) {
+		r.patterns = append(r.patterns, patterns...)
+	}
+}
+
+// RedactReplacement update the replacement of redacted values. It defaults to
+// DefaultRedactReplacement.
+func RedactReplacement(replacement string) RedactorOption {
+	return func(r *Redactor) {
+		r.replacement = replacement
+	}
+}
+
+// RedactHash makes the redactor replace values by their HMAC-SHA256 hash keyed with salt,
+// hex encoded and truncated to 16 characters, so redacted values can still be correlated.
+func RedactHash(salt []byte) RedactorOption {
+	return func(r *Redactor) {
+		r.salt = salt
+		r.hash = true
+	}
+}
+
+// Redactor redacts the fields of the events of loggers configured with the Redact option:
+// top-level fields, including the ones of Map, nested Dict and Object fields, arrays, the
+// values of Any fields, e.g. typed maps or structs, matched as they're encoded in JSON, and
+// context fields.
+type Redactor struct {
+	keys        map[string]struct{}
+	globs       []string
+	patterns    []*regexp.Regexp
+	replacement string
+	salt        []byte
+	hash        bool
+}
+
+// NewRedactor creates a Redactor.
+func NewRedactor(options ...RedactorOption) *Redactor {
+	r := &Redactor{
+		keys:        map[string]struct{}{},
+		replacement: DefaultRedactReplacement,
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// redactEvent redacts the top-level fields of the event from its from-th record.
+func (r *Redactor) redactEvent(e *Event, from int) {
+	for i := from; i < len(e.records); i = nextEntryField(e.records, i) {
+		record := &e.records[i]
+		if r.matchKey(record.key) {
+			e.setField(i, r.replace(entryValue(e.records[i:])))
+			continue
+		}
+		switch record.kind {
+		case kindString:
+			if s, ok := r.redactString(record.str); ok {
+				e.setField(i, s)
+			}
+		case kindBytes, kindRawJSON, kindInterface, kindObject, kindArray, kindSlice:
+			// other values can't hold strings
+			if value, ok := r.redactValue(entryValue(e.records[i:])); ok {
+				e.setField(i, value)
+			}
+		}
+	}
+}
+
+// redactField returns the redacted value of a field, and false if it's unchanged.
+func (r *Redactor) redactField(key string, value interface{}) (interface{}, bool) {
+	if r.matchKey(key) {
+		return r.replace(value), true
+	}
+	return r.redactValue(value)
+}
+
+func (r *Redactor) redactValue(value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case string:
+		return r.redactString(value)
+	case []string:
+		var redacted []string
+		for i, s := range value {
+			if s, ok := r.redactString(s); ok {
+				if redacted == nil {
+					redacted = append([]string(nil), value...)
+				}
+				redacted[i] = s
+			}
+		}
+		return redacted, redacted != nil
+	case []byte:
+		if s, ok := r.redactString(string(value)); ok {
+			return []byte(s), true
+		}
+	case []EntryField:
+		var redacted []EntryField
+		for i, field := range value {
+			if v, ok := r.redactField(field.Key, field.Value); ok {
+				if redacted == nil {
+					redacted = append([]EntryField(nil), value...)
+				}
+				redacted[i].Value = v
+			}
+		}
+		return redacted, redacted != nil
+	case []interface{}:
+		var redacted []interface{}
+		for i, element := range value {
+			if v, ok := r.redactValue(element); ok {
+				if redacted == nil {
+					redacted = append([]interface{}(nil), value...)
+				}
+				redacted[i] = v
+			}
+		}
+		return redacted, redacted != nil
+	case map[string]interface{}:
+		var redacted map[string]interface{}
+		for key, v := range value {
+			if v, ok := r.redactField(key, v); ok {
+				if redacted == nil {
+					redacted = make(map[string]interface{}, len(value))
+					for key, v := range value {
+						redacted[key] = v
+					}
+				}
+				redacted[key] = v
+			}
+		}
+		return redacted, redacted != nil
+	case LogObjectMarshaler:
+		return r.redactValue(objectEntryFields(value))
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64,
+		json.Number, time.Time, time.Duration, []time.Time, []time.Duration, net.IP, net.IPNet, net.HardwareAddr:
+		return value, false
+	}
+	if !mayHoldStrings(reflect.TypeOf(value)) {
+		return value, false
+	}
+	// other values, e.g. typed maps, structs or raw JSON, are matched as they're encoded
+	if normalized, ok := normalizeValue(value); ok {
+		if redacted, ok := r.redactValue(normalized); ok {
+			return redacted, true
+		}
+	}
+	return value, false
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// mayHoldStrings returns false if the values of type t are encoded in JSON without
+// strings, e.g. numbers, bools, or slices and maps of those, so they can't be redacted.
+func mayHoldStrings(t reflect.Type) bool {
+	// nested types are only followed a few levels deep, recursive types can't be ruled out
+	for depth := 0; depth < 8; depth++ {
+		if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+			return true
+		}
+		switch t.Kind() {
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+			return false
+		case reflect.Array, reflect.Slice, reflect.Ptr:
+			t = t.Elem()
+		case reflect.Map:
+			if mayHoldStrings(t.Key()) {
+				return true
+			}
+			t = t.Elem()
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+// normalizeValue returns value encoded in JSON and decoded as a string, a
+// map[string]interface{}, a []interface{} or a json.Number, and false if it can't be
+// encoded.
+func normalizeValue(value interface{}) (interface{}, bool) {
+	b, ok := value.(json.RawMessage)
+	if !ok {
+		var err error
+		if b, err = json.Marshal(value); err != nil {
+			return nil, false
+		}
+	}
+	var normalized interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&normalized); err != nil {
+		return nil, false
+	}
+	return normalized, true
+}
+
+func (r *Redactor) redactString(s string) (string, bool) {
+	redacted := false
+	for _, pattern := range r.patterns {
+		if !pattern.MatchString(s) {
+			continue
+		}
+		s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if pattern == CreditCardPattern && !luhnValid(match) {
+				return match
+			}
+			redacted = true
+			return r.replaceString(match)
+		})
+	}
+	return s, redacted
+}
+
+// luhnValid returns whether the digits of s pass the Luhn check of card numbers. Other
+// characters are ignored.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		digit := int(s[i] - '0')
+		if double {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func (r *Redactor) matchKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	key = strings.ToLower(key)
+	if _, ok := r.keys[key]; ok {
+		return true
+	}
+	for _, glob := range r.globs {
+		if matched, _ := path.Match(glob, key); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// replace returns the replacement of a value whose key matches.
+func (r *Redactor) replace(value interface{}) string {
+	if !r.hash {
+		return r.replacement
+	}
+	if s, ok := value.(string); ok {
+		return r.replaceString(s)
+	}
+	return r.replaceString(fmt.Sprint(value))
+}
+
+func (r *Redactor) replaceString(s string) string {
+	if !r.hash {
+		return r.replacement
+	}
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// redactContext redacts the context fields of the logger.
+func (l *Logger) redactContext() {
+	if len(l.contextRecords) == 0 {
+		return
+	}
+	e := newEvent(nil, 0, l.encoder)
+	e.timeFieldFormat = l.timeFieldFormat
+	e.buf = append(e.buf[:0], l.context...)
+	// the records are kept by the logger, and their data is shared with its other copies
+	e.records = append([]entryField(nil), l.contextRecords...)
+	l.redactor.redactEvent(e, 0)
+	l.context = append(make([]byte, 0, len(e.buf)), e.buf...)
+	l.contextRecords = e.records
+	e.records = nil
+	putEvent(e)
+}
+
+// setField replaces the value of the top-level record i, and its nested records, in the
+// records and the buffer.
+func (e *Event) setField(i int, value interface{}) {
+	r := e.records[i].fieldRange
+	key := e.records[i].key
+	encoded := e.appendEntryValue(appendKeyAt(e.encoder, e.buf, r.start, key), value)
+
+	shift := len(encoded) - (r.end - r.start)
+	end := len(e.buf)
+	if shift > 0 {
+		e.buf = append(e.buf, make([]byte, shift)...)
+	}
+	copy(e.buf[r.end+shift:], e.buf[r.end:end])
+	copy(e.buf[r.start:], encoded)
+	e.buf = e.buf[:end+shift]
+	for j := 0; j < len(e.records); j = nextEntryField(e.records, j) {
+		if e.records[j].start >= r.end {
+			e.records[j].start += shift
+			e.records[j].end += shift
+		}
+	}
+	if e.fieldStart >= r.end {
+		e.fieldStart += shift
+	}
+
+	record := entryField{fieldRange: fieldRange{start: r.start, end: r.end + shift}, key: key}
+	if s, ok := value.(string); ok {
+		record.kind, record.str = kindString, s
+	} else {
+		record.kind, record.value = kindInterface, value
+	}
+	e.records = append(e.records[:i+1], e.records[nextEntryField(e.records, i):]...)
+	e.records[i] = record
+}
+
+// appendEntryValue appends value, as stored in an EntryField, to dst.
+func (e *Event) appendEntryValue(dst []byte, value interface{}) []byte {
+	switch value := value.(type) {
+	case []EntryField:
+		dst = e.encoder.AppendBeginMarker(dst)
+		for _, field := range value {
+			dst = e.appendEntryValue(e.encoder.AppendKey(dst, field.Key), field.Value)
+		}
+		return e.encoder.AppendEndMarker(dst)
+	case []interface{}:
+		dst = e.encoder.AppendArrayStart(dst)
+		for i, element := range value {
+			if i > 0 {
+				dst = e.encoder.AppendArrayDelim(dst)
+			}
+			dst = e.appendEntryValue(dst, element)
+		}
+		return e.encoder.AppendArrayEnd(dst)
+	case json.RawMessage:
+		return appendEmbeddedJSON(e.encoder, dst, value)
+	case time.Time:
+		return e.encoder.AppendTime(dst, value, e.timeFieldFormat)
+	case []time.Time:
+		return e.encoder.AppendTimes(dst, value, e.timeFieldFormat)
+	}
+	return e.appendValue(dst, value)
+}
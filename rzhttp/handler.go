@@ -27,6 +27,8 @@ type httpHandler struct {
 	statusField        string
 	durationField      string
 	requestIDField     string
+	buffer             bool
+	bufferOptions      []rz.BufferOption
 }
 
 // HandlerOption are used to configure a HTTPHandler.
@@ -109,6 +111,17 @@ func RequestID(requestIDFieldName string) HandlerOption {
 	}
 }
 
+// Buffer makes HTTPHandler store a buffered copy of its logger in the requests' context
+// (see rz.Logger.Buffered and rz.FromCtx): the events below the logger's level are only
+// written if an event at or above the trigger level is logged, such as the access log of
+// a request failing with a 5xx status with the default ErrorLevel trigger.
+func Buffer(options ...rz.BufferOption) HandlerOption {
+	return func(handler *httpHandler) {
+		handler.buffer = true
+		handler.bufferOptions = options
+	}
+}
+
 // Handler is a helper middleware to log HTTP requests
 func Handler(logger rz.Logger, options ...HandlerOption) func(next http.Handler) http.Handler {
 	logger = logger.With()
@@ -182,6 +195,14 @@ func Handler(logger rz.Logger, options ...HandlerOption) func(next http.Handler)
 				handler.logger.Append(rz.String(handler.userAgentField, r.Header.Get("user-agent")))
 			}
 
+			var buffer *rz.LogBuffer
+			if handler.buffer {
+				var requestLogger rz.Logger
+				requestLogger, buffer = handler.logger.Buffered(handler.bufferOptions...)
+				r = r.WithContext(requestLogger.ToCtx(r.Context()))
+				handler.logger = handler.logger.With(rz.Writer(buffer))
+			}
+
 			next.ServeHTTP(resWrapper, r)
 
 			if handler.sizeField != "" {
@@ -219,6 +240,10 @@ func Handler(logger rz.Logger, options ...HandlerOption) func(next http.Handler)
 			default:
 				handler.logger.Error(handler.message)
 			}
+
+			if buffer != nil {
+				buffer.Discard()
+			}
 		})
 	}
 }
@@ -0,0 +1,38 @@
+package rzhttp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skerkour/rz"
+)
+
+func TestHandlerBuffer(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := rz.New(rz.Writer(out), rz.Level(rz.InfoLevel), rz.Fields(rz.Timestamp(false)))
+	handler := Handler(logger, Buffer(), URL(""), Method(""), Scheme(""), Host(""), RemoteAddress(""),
+		UserAgent(""), Size(""), Duration(""), RequestID(""))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rz.FromCtx(r.Context()).Debug("querying")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if got, want := out.String(), `{"level":"info","status":200,"message":"access"}`+"\n"; got != want {
+		t.Errorf("invalid log output for success:\ngot:  %v\nwant: %v", got, want)
+	}
+
+	out.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	want := []string{
+		`{"level":"debug","message":"querying"}`,
+		`{"level":"error","status":500,"message":"access"}`,
+	}
+	if got := strings.Split(strings.TrimSpace(out.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("invalid log output for failure:\ngot:  %v\nwant: %v", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package rzhttp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/skerkour/rz"
+)
+
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler returns an http.Handler to read and update level at runtime.
+// GET returns the current level and PUT updates it, either as JSON ({"level":"debug"})
+// or as plain text (debug), following the request's Content-Type for PUT and Accept
+// header for GET. The response uses the same format as the request.
+func LevelHandler(level *rz.AtomicLevel) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeLevel(w, level.Level(), acceptsJSON(r))
+		case http.MethodPut:
+			isJSON := isJSONContentType(r.Header.Get("Content-Type"))
+			body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1024))
+			if err != nil {
+				writeLevelError(w, http.StatusBadRequest, err.Error(), isJSON)
+				return
+			}
+			levelStr := strings.TrimSpace(string(body))
+			if isJSON {
+				var payload levelPayload
+				if err = json.Unmarshal(body, &payload); err != nil {
+					writeLevelError(w, http.StatusBadRequest, err.Error(), isJSON)
+					return
+				}
+				levelStr = payload.Level
+			}
+			newLevel, err := rz.ParseLevel(levelStr)
+			if err != nil {
+				writeLevelError(w, http.StatusBadRequest, err.Error(), isJSON)
+				return
+			}
+			level.SetLevel(newLevel)
+			writeLevel(w, newLevel, isJSON)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeLevelError(w, http.StatusMethodNotAllowed, "only GET and PUT are supported", acceptsJSON(r))
+		}
+	})
+}
+
+func writeLevel(w http.ResponseWriter, level rz.LogLevel, isJSON bool) {
+	// unlike String, MarshalText names the disabled level
+	levelStr, _ := level.MarshalText()
+	if isJSON {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(levelPayload{Level: string(levelStr)})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(append(levelStr, '\n'))
+}
+
+func writeLevelError(w http.ResponseWriter, status int, message string, isJSON bool) {
+	if isJSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(struct {
+			Error string `json:"error"`
+		}{message})
+		return
+	}
+	http.Error(w, message, status)
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json"
+}
+
+func acceptsJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return accept == "" || strings.Contains(accept, "application/json")
+}
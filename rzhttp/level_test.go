@@ -0,0 +1,54 @@
+package rzhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skerkour/rz"
+)
+
+func TestLevelHandler(t *testing.T) {
+	level := rz.NewAtomicLevel(rz.InfoLevel)
+	handler := LevelHandler(level)
+
+	tests := []struct {
+		method      string
+		contentType string
+		accept      string
+		body        string
+		wantStatus  int
+		wantBody    string
+		wantLevel   rz.LogLevel
+	}{
+		{http.MethodGet, "", "", "", http.StatusOK, `{"level":"info"}` + "\n", rz.InfoLevel},
+		{http.MethodGet, "", "text/plain", "", http.StatusOK, "info\n", rz.InfoLevel},
+		{http.MethodPut, "application/json", "", `{"level":"debug"}`, http.StatusOK, `{"level":"debug"}` + "\n", rz.DebugLevel},
+		{http.MethodPut, "text/plain", "", "error\n", http.StatusOK, "error\n", rz.ErrorLevel},
+		{http.MethodPut, "text/plain", "", "loud", http.StatusBadRequest, "", rz.ErrorLevel},
+		{http.MethodPut, "application/json", "", `{"level":`, http.StatusBadRequest, "", rz.ErrorLevel},
+		{http.MethodPost, "", "", "", http.StatusMethodNotAllowed, "", rz.ErrorLevel},
+		{http.MethodPut, "text/plain", "", "off", http.StatusOK, "disabled\n", rz.Disabled},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/level", strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s %q: status = %d, want %d", tt.method, tt.body, w.Code, tt.wantStatus)
+		}
+		if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+			t.Errorf("%s %q: body = %q, want %q", tt.method, tt.body, w.Body.String(), tt.wantBody)
+		}
+		if got := level.Level(); got != tt.wantLevel {
+			t.Errorf("%s %q: level = %v, want %v", tt.method, tt.body, got, tt.wantLevel)
+		}
+	}
+}
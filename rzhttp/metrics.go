@@ -0,0 +1,28 @@
+package rzhttp
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/skerkour/rz"
+)
+
+// MetricsHandler returns an http.Handler serving metrics in the Prometheus text exposition
+// format, or in JSON, as published with expvar, if the request's Accept header asks for
+// application/json.
+func MetricsHandler(metrics *rz.Metrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "only GET and HEAD are supported", http.StatusMethodNotAllowed)
+			return
+		}
+		if strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(metrics.String() + "\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		metrics.WritePrometheus(w)
+	})
+}
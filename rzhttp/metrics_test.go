@@ -0,0 +1,69 @@
+package rzhttp
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skerkour/rz"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	metrics := rz.NewMetrics(rz.MetricsField(rz.DefaultComponentFieldName))
+	log := rz.New(rz.Writer(&bytes.Buffer{}), rz.AddPostHook(metrics))
+	db := log.Named("db")
+	db.Info("query")
+	db.Error("failed")
+
+	server := httptest.NewServer(MetricsHandler(metrics))
+	defer server.Close()
+
+	tests := []struct {
+		accept      string
+		contentType string
+		want        []string
+	}{
+		{"", "text/plain; version=0.0.4; charset=utf-8", []string{
+			"# TYPE rz_log_events_total counter\n",
+			`rz_log_events_total{level="info",component="db"} 1` + "\n",
+			`rz_log_events_total{level="error",component="db"} 1` + "\n",
+			"# TYPE rz_log_bytes_total counter\n",
+		}},
+		{"application/json", "application/json", []string{
+			`"by_component":{"db":{"bytes":{`,
+			`"events":{"error":1,"info":1}`,
+		}},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if got := resp.Header.Get("Content-Type"); got != tt.contentType {
+			t.Errorf("Accept %q: Content-Type = %q, want %q", tt.accept, got, tt.contentType)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("Accept %q: body doesn't contain %q:\n%s", tt.accept, want, body)
+			}
+		}
+	}
+
+	resp, err := http.Post(server.URL, "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
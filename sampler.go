@@ -1,7 +1,12 @@
 package rz
 
 import (
+	"fmt"
+	"math"
 	"math/rand"
+	"runtime"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -13,6 +18,16 @@ const (
 	SampleSometimes = SamplerRandom(100)
 	// SampleRarely samples log every ~ 1000 events.
 	SampleRarely = SamplerRandom(1000)
+
+	// DefaultSamplerDedupSize is the default number of counters of a SamplerDedup.
+	DefaultSamplerDedupSize = 4096
+
+	// DefaultSamplerAdaptiveWindow is the default window of a SamplerAdaptive.
+	DefaultSamplerAdaptiveWindow = time.Second
+
+	// DefaultSamplerTokenBucketSize is the default number of buckets of a SamplerTokenBucket
+	// with per-key buckets.
+	DefaultSamplerTokenBucketSize = 4096
 )
 
 // LogSampler defines an interface to a log sampler.
@@ -22,6 +37,21 @@ type LogSampler interface {
 	Sample(lvl LogLevel) bool
 }
 
+// EventSampler is a LogSampler which can also see the message and the fields of the
+// events. Loggers call SampleEvent instead of Sample, once the event's fields are added.
+type EventSampler interface {
+	LogSampler
+	// SampleEvent returns true if the event should be part of the sample, false if
+	// the event should be dropped.
+	SampleEvent(lvl LogLevel, message string, e *Event) bool
+}
+
+// entrySampler is implemented by samplers reading the fields of the event's entry, which
+// loggers then have to collect.
+type entrySampler interface {
+	usesEntry() bool
+}
+
 // SamplerRandom use a PRNG to randomly sample an event out of N events,
 // regardless of their level.
 type SamplerRandom uint32
@@ -98,17 +128,24 @@ func (s *SamplerBurst) inc() uint32 {
 	return c
 }
 
-// SamplerLevel applies a different sampler for each level.
+// SamplerLevel applies a different sampler for each level. The samplers of custom levels
+// are looked up in CustomSamplers by level.
 type SamplerLevel struct {
-	DebugSampler LogSampler
-	InfoSampler  LogSampler
-	WarnSampler  LogSampler
-	ErrorSampler LogSampler
+	TraceSampler   LogSampler
+	DebugSampler   LogSampler
+	InfoSampler    LogSampler
+	WarnSampler    LogSampler
+	ErrorSampler   LogSampler
+	CustomSamplers map[LogLevel]LogSampler
 }
 
 // Sample implements the Sampler interface.
 func (s SamplerLevel) Sample(lvl LogLevel) bool {
 	switch lvl {
+	case TraceLevel:
+		if s.TraceSampler != nil {
+			return s.TraceSampler.Sample(lvl)
+		}
 	case DebugLevel:
 		if s.DebugSampler != nil {
 			return s.DebugSampler.Sample(lvl)
@@ -125,6 +162,352 @@ func (s SamplerLevel) Sample(lvl LogLevel) bool {
 		if s.ErrorSampler != nil {
 			return s.ErrorSampler.Sample(lvl)
 		}
+	default:
+		if sampler := s.CustomSamplers[lvl]; sampler != nil {
+			return sampler.Sample(lvl)
+		}
 	}
 	return true
 }
+
+// SamplerDedup lets the First events of each level and message pass per Period, then
+// every Thereafter-th one, so a noisy message doesn't use the budget of the others.
+// The values of the Fields fields of the events, if any, are part of the key too.
+//
+// Counters live in a fixed table of Size counters, sharded by the keys' hash: keys
+// sharing a counter are counted together.
+type SamplerDedup struct {
+	// First is the number of events of each key let through per period.
+	First uint64
+	// Thereafter lets every Thereafter-th event of each key through once First is
+	// reached. If 0, events are rejected until the end of the period.
+	Thereafter uint64
+	// Period defines the period after which the counters reset. If 0, they never reset.
+	Period time.Duration
+	// Fields are the names of the top-level fields whose values are part of the key.
+	Fields []string
+	// Size is the number of counters. It defaults to DefaultSamplerDedupSize.
+	Size int
+
+	once     sync.Once
+	counters []dedupCounter
+}
+
+type dedupCounter struct {
+	resetAt int64
+	counter uint64
+}
+
+// Sample implements the Sampler interface. The key is the level only.
+func (s *SamplerDedup) Sample(lvl LogLevel) bool {
+	return s.sample(fnvAddByte(fnvOffset64, byte(lvl)))
+}
+
+// SampleEvent implements the EventSampler interface.
+func (s *SamplerDedup) SampleEvent(lvl LogLevel, message string, e *Event) bool {
+	key := fnvAddString(fnvAddByte(fnvOffset64, byte(lvl)), message)
+	for _, name := range s.Fields {
+		key = fnvAddByte(key, 0)
+		for i := 0; i < len(e.records); i = nextEntryField(e.records, i) {
+			if e.records[i].key == name {
+				key = fnvAddString(key, dedupFieldValue(e.records[i:]))
+				break
+			}
+		}
+	}
+	return s.sample(key)
+}
+
+func (s *SamplerDedup) usesEntry() bool {
+	return len(s.Fields) > 0
+}
+
+func (s *SamplerDedup) sample(key uint64) bool {
+	s.once.Do(func() {
+		size := s.Size
+		if size <= 0 {
+			size = DefaultSamplerDedupSize
+		}
+		s.counters = make([]dedupCounter, size)
+	})
+	c := s.counters[key%uint64(len(s.counters))].inc(s.Period)
+	if c <= s.First {
+		return true
+	}
+	return s.Thereafter > 0 && (c-s.First)%s.Thereafter == 0
+}
+
+func (c *dedupCounter) inc(period time.Duration) uint64 {
+	if period <= 0 {
+		return atomic.AddUint64(&c.counter, 1)
+	}
+	now := time.Now().UnixNano()
+	resetAt := atomic.LoadInt64(&c.resetAt)
+	if now > resetAt {
+		if atomic.CompareAndSwapInt64(&c.resetAt, resetAt, now+period.Nanoseconds()) {
+			atomic.StoreUint64(&c.counter, 1)
+			return 1
+		}
+		// Lost the race with another goroutine trying to reset.
+	}
+	return atomic.AddUint64(&c.counter, 1)
+}
+
+func dedupFieldValue(records []entryField) string {
+	switch f := &records[0]; f.kind {
+	case kindString:
+		return f.str
+	case kindBool:
+		return strconv.FormatBool(f.num != 0)
+	case kindInt, kindInt8, kindInt16, kindInt32, kindInt64:
+		return strconv.FormatInt(int64(f.num), 10)
+	case kindUint, kindUint8, kindUint16, kindUint32, kindUint64:
+		return strconv.FormatUint(f.num, 10)
+	default:
+		return fmt.Sprint(entryValue(records))
+	}
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// fnvAddByte and fnvAddString compute FNV-1a hashes without allocating.
+func fnvAddByte(h uint64, b byte) uint64 {
+	return (h ^ uint64(b)) * fnvPrime64
+}
+
+func fnvAddString(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h = (h ^ uint64(s[i])) * fnvPrime64
+	}
+	return h
+}
+
+// SamplerAdaptive keeps about EventsPerSecond events per second for each level, whatever
+// the volume: the keep probability of each level is recomputed at the end of each Window
+// from the number of events logged during the window, and applied during the next one.
+// Error and above levels are always kept, unless configured in LevelEventsPerSecond.
+//
+// Kept events get a RateFieldName field holding the inverse of their keep probability,
+// e.g. 20 if 1 event on 20 is kept, so downstream tools can re-weight counts.
+type SamplerAdaptive struct {
+	levels [256]adaptiveLevel // first for 64-bit alignment of atomic operations on 32-bit platforms
+
+	// EventsPerSecond is the target number of events kept per second for each level
+	// below ErrorLevel. If 0, these levels are not sampled.
+	EventsPerSecond float64
+	// LevelEventsPerSecond overrides EventsPerSecond for the given levels, including
+	// ErrorLevel and above.
+	LevelEventsPerSecond map[LogLevel]float64
+	// Window is the period over which the volume is observed. It defaults to
+	// DefaultSamplerAdaptiveWindow.
+	Window time.Duration
+	// RateFieldName is the name of the sample rate field. It defaults to
+	// DefaultSampleRateFieldName.
+	RateFieldName string
+}
+
+// adaptiveLevel only holds 64-bit words, so each level of an aligned array is aligned.
+type adaptiveLevel struct {
+	windowEnd int64
+	count     uint64
+	rate      uint64 // math.Float64bits of the sample rate, 0 meaning 1
+}
+
+// Sample implements the Sampler interface.
+func (s *SamplerAdaptive) Sample(lvl LogLevel) bool {
+	_, keep := s.sample(lvl)
+	return keep
+}
+
+// SampleEvent implements the EventSampler interface.
+func (s *SamplerAdaptive) SampleEvent(lvl LogLevel, message string, e *Event) bool {
+	rate, keep := s.sample(lvl)
+	if keep && rate > 0 {
+		fieldName := s.RateFieldName
+		if fieldName == "" {
+			fieldName = DefaultSampleRateFieldName
+		}
+		e.float64(fieldName, rate)
+	}
+	return keep
+}
+
+// sample returns the sample rate of lvl, 0 if lvl is not sampled, and whether the event
+// should be kept.
+func (s *SamplerAdaptive) sample(lvl LogLevel) (float64, bool) {
+	target, ok := s.LevelEventsPerSecond[lvl]
+	if !ok {
+		if lvl.Severity() >= ErrorLevel.Severity() || s.EventsPerSecond <= 0 {
+			return 0, true
+		}
+		target = s.EventsPerSecond
+	}
+	window := s.Window
+	if window <= 0 {
+		window = DefaultSamplerAdaptiveWindow
+	}
+	rate := s.levels[lvl].observe(time.Now().UnixNano(), window.Nanoseconds(), target)
+	if rate > 1 && rand.Float64()*rate >= 1 {
+		return rate, false
+	}
+	return rate, true
+}
+
+// observe counts an event and returns the current sample rate, recomputed from the
+// volume of the previous window once it's over.
+func (l *adaptiveLevel) observe(now, window int64, target float64) float64 {
+	windowEnd := atomic.LoadInt64(&l.windowEnd)
+	if now >= windowEnd && atomic.CompareAndSwapInt64(&l.windowEnd, windowEnd, now+window) {
+		count := atomic.SwapUint64(&l.count, 0)
+		rate := 1.0
+		if windowEnd != 0 {
+			// the window may have been extended by a period without events
+			elapsed := float64(now-windowEnd+window) / float64(time.Second)
+			if expected := target * elapsed; float64(count) > expected {
+				rate = float64(count) / expected
+			}
+		}
+		atomic.StoreUint64(&l.rate, math.Float64bits(rate))
+	}
+	atomic.AddUint64(&l.count, 1)
+	if rate := math.Float64frombits(atomic.LoadUint64(&l.rate)); rate > 1 {
+		return rate
+	}
+	return 1
+}
+
+// TokenBucketKey defines the buckets of a SamplerTokenBucket.
+type TokenBucketKey uint8
+
+const (
+	// TokenBucketKeyLevel uses a bucket per level.
+	TokenBucketKeyLevel TokenBucketKey = iota
+	// TokenBucketKeyMessage uses a bucket per level and message.
+	TokenBucketKeyMessage
+	// TokenBucketKeyCaller uses a bucket per level and caller site.
+	TokenBucketKeyCaller
+)
+
+// TokenBucketLimit is the budget of a token bucket.
+type TokenBucketLimit struct {
+	// Rate is the number of events allowed per second, in the long run. If 0, events are
+	// not limited.
+	Rate float64
+	// Burst is the number of events allowed at once, at least 1.
+	Burst int
+}
+
+// SamplerTokenBucket limits events with token buckets: each bucket allows Burst events at
+// once, and is refilled at Rate events per second. Buckets are accounted lock-free.
+// The number of events denied since the previous allowed one is added to the allowed
+// events as a RateLimitedFieldName field.
+//
+// Per-key buckets live in a fixed table of Size buckets, sharded by the keys' hash: keys
+// sharing a bucket share its budget.
+type SamplerTokenBucket struct {
+	// Limit is the budget of the buckets of every level.
+	Limit TokenBucketLimit
+	// LevelLimits overrides Limit for the given levels.
+	LevelLimits map[LogLevel]TokenBucketLimit
+	// Key defines the buckets: per level by default, or per level and message or caller site.
+	Key TokenBucketKey
+	// Size is the number of buckets for per-key buckets. It defaults to
+	// DefaultSamplerTokenBucketSize.
+	Size int
+	// RateLimitedFieldName is the name of the denied events field. It defaults to
+	// DefaultRateLimitedFieldName.
+	RateLimitedFieldName string
+
+	once    sync.Once
+	buckets []tokenBucket
+}
+
+type tokenBucket struct {
+	// tat is the theoretical arrival time of the next event, in nanoseconds, as in the
+	// generic cell rate algorithm.
+	tat    int64
+	denied uint64
+}
+
+// Sample implements the Sampler interface.
+func (s *SamplerTokenBucket) Sample(lvl LogLevel) bool {
+	allowed, _ := s.sample(lvl, uint64(lvl))
+	return allowed
+}
+
+// SampleEvent implements the EventSampler interface.
+func (s *SamplerTokenBucket) SampleEvent(lvl LogLevel, message string, e *Event) bool {
+	key := uint64(lvl)
+	switch s.Key {
+	case TokenBucketKeyMessage:
+		key = fnvAddString(fnvAddByte(fnvOffset64, byte(lvl)), message)
+	case TokenBucketKeyCaller:
+		// SampleEvent is called at the same depth as the caller field is computed
+		var pc [1]uintptr
+		runtime.Callers(e.callerSkipFrameCount+1, pc[:])
+		key = fnvAddByte(fnvOffset64, byte(lvl)) ^ uint64(pc[0])*fnvPrime64
+	}
+	allowed, denied := s.sample(lvl, key)
+	if allowed && denied > 0 {
+		fieldName := s.RateLimitedFieldName
+		if fieldName == "" {
+			fieldName = DefaultRateLimitedFieldName
+		}
+		e.uint64(fieldName, denied)
+	}
+	return allowed
+}
+
+// sample returns whether the event is allowed and, if so, the number of events denied
+// by its bucket since the previous allowed one.
+func (s *SamplerTokenBucket) sample(lvl LogLevel, key uint64) (bool, uint64) {
+	limit, ok := s.LevelLimits[lvl]
+	if !ok {
+		limit = s.Limit
+	}
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+	s.once.Do(func() {
+		size := 256
+		if s.Key != TokenBucketKeyLevel {
+			size = s.Size
+			if size <= 0 {
+				size = DefaultSamplerTokenBucketSize
+			}
+		}
+		s.buckets = make([]tokenBucket, size)
+	})
+	bucket := &s.buckets[key%uint64(len(s.buckets))]
+	burst := int64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	if !bucket.allow(time.Now().UnixNano(), int64(float64(time.Second)/limit.Rate), burst) {
+		atomic.AddUint64(&bucket.denied, 1)
+		return false, 0
+	}
+	return true, atomic.SwapUint64(&bucket.denied, 0)
+}
+
+// allow takes a token from the bucket, refilled every interval nanoseconds up to burst
+// tokens.
+func (b *tokenBucket) allow(now, interval, burst int64) bool {
+	for {
+		tat := atomic.LoadInt64(&b.tat)
+		newTat := tat
+		if now > newTat {
+			newTat = now
+		}
+		newTat += interval
+		if newTat-now > burst*interval {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&b.tat, tat, newTat) {
+			return true
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package rz
 
 import (
+	"bytes"
+	"math"
 	"testing"
 	"time"
 )
@@ -47,6 +49,13 @@ var samplers = []struct {
 		},
 		120, 40, 40,
 	},
+	{
+		"SamplerDedup",
+		func() LogSampler {
+			return &SamplerDedup{First: 20, Thereafter: 10, Period: time.Second}
+		},
+		100, 28, 28,
+	},
 }
 
 func TestSamplers(t *testing.T) {
@@ -80,3 +89,144 @@ func BenchmarkSamplers(b *testing.B) {
 		})
 	}
 }
+
+func TestSamplerDedup(t *testing.T) {
+	out := &bytes.Buffer{}
+	sampler := &SamplerDedup{First: 2, Thereafter: 3, Period: time.Hour}
+	log := New(Writer(out), Fields(Timestamp(false)), Sampler(sampler))
+	for i := 0; i < 8; i++ {
+		log.Info("noisy", Int("i", i))
+	}
+	log.Info("quiet")
+	log.Warn("noisy")
+	want := `{"level":"info","i":0,"message":"noisy"}
+{"level":"info","i":1,"message":"noisy"}
+{"level":"info","i":4,"message":"noisy"}
+{"level":"info","i":7,"message":"noisy"}
+{"level":"info","message":"quiet"}
+{"level":"warning","message":"noisy"}
+`
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:\n%v\nwant:\n%v", got, want)
+	}
+
+	out.Reset()
+	sampler = &SamplerDedup{First: 1, Fields: []string{"user"}}
+	log = New(Writer(out), Fields(Timestamp(false)), Sampler(sampler))
+	for i := 0; i < 3; i++ {
+		log.Info("login", String("user", "alice"))
+		log.Info("login", String("user", "bob"))
+		log.Info("login")
+	}
+	want = `{"level":"info","user":"alice","message":"login"}
+{"level":"info","user":"bob","message":"login"}
+{"level":"info","message":"login"}
+`
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output with fields:\ngot:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestSamplerAdaptive(t *testing.T) {
+	var level adaptiveLevel
+	second := time.Second.Nanoseconds()
+	for i := 0; i < 100; i++ {
+		if rate := level.observe(second+int64(i), second, 10); rate != 1 {
+			t.Fatalf("first window rate = %v, want 1", rate)
+		}
+	}
+	if rate := level.observe(2*second, second, 10); rate != 10 {
+		t.Errorf("rate after 100 events/s = %v, want 10", rate)
+	}
+	// 5 events in 2 windows: below the target
+	for i := 0; i < 4; i++ {
+		level.observe(2*second+int64(i), second, 10)
+	}
+	if rate := level.observe(4*second, second, 10); rate != 1 {
+		t.Errorf("rate after 2.5 events/s = %v, want 1", rate)
+	}
+
+	out := &bytes.Buffer{}
+	sampler := &SamplerAdaptive{EventsPerSecond: 10, LevelEventsPerSecond: map[LogLevel]float64{FatalLevel: 1}}
+	log := New(Writer(out), Fields(Timestamp(false)), Sampler(sampler))
+	log.Info("info")
+	log.Error("error")
+	log.LogWithLevel(FatalLevel, "fatal")
+	want := `{"level":"info","sample_rate":1,"message":"info"}
+{"level":"error","message":"error"}
+{"level":"fatal","sample_rate":1,"message":"fatal"}
+`
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:\n%v\nwant:\n%v", got, want)
+	}
+
+	sampler = &SamplerAdaptive{EventsPerSecond: 10}
+	sampler.levels[InfoLevel] = adaptiveLevel{windowEnd: math.MaxInt64, rate: math.Float64bits(10)}
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		if sampler.Sample(InfoLevel) {
+			kept++
+		}
+	}
+	if kept < 800 || kept > 1200 {
+		t.Errorf("kept %d events on 10000 with a sample rate of 10, want ~1000", kept)
+	}
+}
+
+func TestSamplerTokenBucket(t *testing.T) {
+	var bucket tokenBucket
+	second := time.Second.Nanoseconds()
+	now := 10 * second
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if bucket.allow(now, second, 3) {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("allowed %d events at once with a burst of 3", allowed)
+	}
+	if bucket.allow(now+second/2, second, 3) {
+		t.Error("event allowed before refill")
+	}
+	if !bucket.allow(now+second, second, 3) || bucket.allow(now+second, second, 3) {
+		t.Error("a token should be refilled after the interval")
+	}
+
+	out := &bytes.Buffer{}
+	sampler := &SamplerTokenBucket{
+		Limit:       TokenBucketLimit{Rate: 1.0 / 3600, Burst: 2},
+		LevelLimits: map[LogLevel]TokenBucketLimit{ErrorLevel: {}},
+	}
+	log := New(Writer(out), Fields(Timestamp(false)), Sampler(sampler))
+	for i := 0; i < 5; i++ {
+		log.Info("info", Int("i", i))
+	}
+	log.Error("error")
+	log.Error("error")
+	sampler.buckets[InfoLevel].tat = 0
+	log.Info("info", Int("i", 5))
+	want := `{"level":"info","i":0,"message":"info"}
+{"level":"info","i":1,"message":"info"}
+{"level":"error","message":"error"}
+{"level":"error","message":"error"}
+{"level":"info","i":5,"rate_limited":3,"message":"info"}
+`
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:\n%v\nwant:\n%v", got, want)
+	}
+
+	out.Reset()
+	sampler = &SamplerTokenBucket{Limit: TokenBucketLimit{Rate: 1.0 / 3600, Burst: 1}, Key: TokenBucketKeyCaller}
+	log = New(Writer(out), Fields(Timestamp(false)), Sampler(sampler))
+	for i := 0; i < 3; i++ {
+		log.Info("first site")
+		log.Info("second site")
+	}
+	want = `{"level":"info","message":"first site"}
+{"level":"info","message":"second site"}
+`
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output with per caller buckets:\ngot:\n%v\nwant:\n%v", got, want)
+	}
+}
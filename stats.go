@@ -0,0 +1,214 @@
+package rz
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSamplingReportInterval is the default interval of SamplingStats.Report.
+const DefaultSamplingReportInterval = time.Minute
+
+// DropReason is the reason why an event was dropped.
+type DropReason uint8
+
+const (
+	// DropLevel is used for events below the logger's level.
+	DropLevel DropReason = iota
+	// DropSampler is used for events rejected by the logger's sampler.
+	DropSampler
+	// DropDiscard is used for events discarded by a hook or the Discard field.
+	DropDiscard
+
+	dropReasonCount
+)
+
+func (r DropReason) String() string {
+	switch r {
+	case DropLevel:
+		return "level"
+	case DropSampler:
+		return "sampler"
+	case DropDiscard:
+		return "discard"
+	}
+	return ""
+}
+
+// SamplingStats counts the events considered, kept and dropped by loggers, by level and
+// by drop reason. Loggers feed it when set with the WithSamplingStats option.
+//
+// A SamplingStats is safe for concurrent use.
+type SamplingStats struct {
+	levels [256]levelStats
+}
+
+type levelStats struct {
+	considered uint64
+	kept       uint64
+	dropped    [dropReasonCount]uint64
+}
+
+// SamplingSnapshot holds the counters of a SamplingStats at a point in time. Levels
+// without events are omitted.
+type SamplingSnapshot struct {
+	Considered map[LogLevel]uint64
+	Kept       map[LogLevel]uint64
+	Dropped    map[DropReason]map[LogLevel]uint64
+}
+
+// NewSamplingStats creates a SamplingStats.
+func NewSamplingStats() *SamplingStats {
+	return &SamplingStats{}
+}
+
+// Considered returns the number of events of level considered by loggers.
+func (s *SamplingStats) Considered(level LogLevel) uint64 {
+	return atomic.LoadUint64(&s.levels[level].considered)
+}
+
+// Kept returns the number of events of level written by loggers.
+func (s *SamplingStats) Kept(level LogLevel) uint64 {
+	return atomic.LoadUint64(&s.levels[level].kept)
+}
+
+// Dropped returns the number of events of level dropped for reason.
+func (s *SamplingStats) Dropped(level LogLevel, reason DropReason) uint64 {
+	return atomic.LoadUint64(&s.levels[level].dropped[reason])
+}
+
+// Snapshot returns the current counters.
+func (s *SamplingStats) Snapshot() SamplingSnapshot {
+	snapshot := SamplingSnapshot{
+		Considered: map[LogLevel]uint64{},
+		Kept:       map[LogLevel]uint64{},
+		Dropped:    map[DropReason]map[LogLevel]uint64{},
+	}
+	for i := range s.levels {
+		level := LogLevel(i)
+		if considered := s.Considered(level); considered > 0 {
+			snapshot.Considered[level] = considered
+		}
+		if kept := s.Kept(level); kept > 0 {
+			snapshot.Kept[level] = kept
+		}
+		for reason := DropReason(0); reason < dropReasonCount; reason++ {
+			if dropped := s.Dropped(level, reason); dropped > 0 {
+				if snapshot.Dropped[reason] == nil {
+					snapshot.Dropped[reason] = map[LogLevel]uint64{}
+				}
+				snapshot.Dropped[reason][level] = dropped
+			}
+		}
+	}
+	return snapshot
+}
+
+// Report logs a summary of the events considered, kept and dropped during each interval
+// with logger, without level, such as:
+//
+//	{"considered":{"debug":1300,"info":20},"kept":{"debug":66,"info":20},"dropped":{"debug":1234},"dropped_by":{"sampler":{"debug":1234}},"message":"rz sampling summary"}
+//
+// Summaries are not logged for intervals without events. The summaries themselves are
+// neither sampled nor counted. Report returns a function stopping the reports.
+func (s *SamplingStats) Report(logger Logger, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultSamplingReportInterval
+	}
+	logger = logger.With(Sampler(nil), WithSamplingStats(nil))
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		previous := s.Snapshot()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				current := s.Snapshot()
+				s.report(&logger, previous, current)
+				previous = current
+			}
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
+
+func (s *SamplingStats) report(logger *Logger, previous, current SamplingSnapshot) {
+	considered := logger.NewDict()
+	hasEvents := false
+	for i := range s.levels {
+		level := LogLevel(i)
+		if n := current.Considered[level] - previous.Considered[level]; n > 0 {
+			considered.uint64(statsLevelName(level), n)
+			hasEvents = true
+		}
+	}
+	if !hasEvents {
+		return
+	}
+	kept := logger.NewDict()
+	dropped := logger.NewDict()
+	droppedBy := logger.NewDict()
+	for i := range s.levels {
+		level := LogLevel(i)
+		if n := current.Kept[level] - previous.Kept[level]; n > 0 {
+			kept.uint64(statsLevelName(level), n)
+		}
+		var total uint64
+		for reason := DropReason(0); reason < dropReasonCount; reason++ {
+			total += current.Dropped[reason][level] - previous.Dropped[reason][level]
+		}
+		if total > 0 {
+			dropped.uint64(statsLevelName(level), total)
+		}
+	}
+	for reason := DropReason(0); reason < dropReasonCount; reason++ {
+		reasonDropped := logger.NewDict()
+		hasDropped := false
+		for i := range s.levels {
+			level := LogLevel(i)
+			if n := current.Dropped[reason][level] - previous.Dropped[reason][level]; n > 0 {
+				reasonDropped.uint64(statsLevelName(level), n)
+				hasDropped = true
+			}
+		}
+		if hasDropped {
+			droppedBy.dict(reason.String(), reasonDropped)
+		}
+	}
+	logger.Log("rz sampling summary",
+		Dict("considered", considered),
+		Dict("kept", kept),
+		Dict("dropped", dropped),
+		Dict("dropped_by", droppedBy),
+	)
+}
+
+func (s *SamplingStats) consider(level LogLevel) {
+	if s != nil {
+		atomic.AddUint64(&s.levels[level].considered, 1)
+	}
+}
+
+func (s *SamplingStats) keep(level LogLevel) {
+	if s != nil {
+		atomic.AddUint64(&s.levels[level].kept, 1)
+	}
+}
+
+func (s *SamplingStats) drop(level LogLevel, reason DropReason) {
+	if s != nil {
+		atomic.AddUint64(&s.levels[level].dropped[reason], 1)
+	}
+}
+
+// statsLevelName returns the name of level in summaries, "nolevel" for NoLevel.
+func statsLevelName(level LogLevel) string {
+	if level == NoLevel {
+		return "nolevel"
+	}
+	return level.String()
+}
@@ -0,0 +1,58 @@
+package rz
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSamplingStats(t *testing.T) {
+	stats := NewSamplingStats()
+	discardHook := HookFunc(func(e *Event, level LogLevel, message string) {
+		if message == "secret" {
+			e.Append(Discard())
+		}
+	})
+	log := New(Writer(&bytes.Buffer{}), Level(InfoLevel), Sampler(&SamplerBasic{N: 2}), Hooks(discardHook), WithSamplingStats(stats))
+	for i := 0; i < 3; i++ {
+		log.Debug("debug")
+	}
+	for i := 0; i < 4; i++ {
+		log.Info("info")
+	}
+	child := log.Named("child")
+	child.Warn("secret")
+	log.Warn("warn")
+
+	want := SamplingSnapshot{
+		Considered: map[LogLevel]uint64{DebugLevel: 3, InfoLevel: 4, WarnLevel: 2},
+		Kept:       map[LogLevel]uint64{InfoLevel: 2},
+		Dropped: map[DropReason]map[LogLevel]uint64{
+			DropLevel:   {DebugLevel: 3},
+			DropSampler: {InfoLevel: 2, WarnLevel: 1},
+			DropDiscard: {WarnLevel: 1},
+		},
+	}
+	got := stats.Snapshot()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot() = %+v\nwant %+v", got, want)
+	}
+	if stats.Dropped(InfoLevel, DropSampler) != 2 || stats.Kept(InfoLevel) != 2 || stats.Considered(WarnLevel) != 2 {
+		t.Errorf("invalid counters: %+v", got)
+	}
+
+	out := &bytes.Buffer{}
+	reporter := New(Writer(out), Fields(Timestamp(false)))
+	stats.report(&reporter, SamplingSnapshot{}, got)
+	wantSummary := `{"considered":{"debug":3,"info":4,"warning":2},"kept":{"info":2},"dropped":{"debug":3,"info":2,"warning":2},` +
+		`"dropped_by":{"level":{"debug":3},"sampler":{"info":2,"warning":1},"discard":{"warning":1}},"message":"rz sampling summary"}` + "\n"
+	if got := out.String(); got != wantSummary {
+		t.Errorf("invalid summary:\ngot:  %v\nwant: %v", got, wantSummary)
+	}
+
+	out.Reset()
+	stats.report(&reporter, got, got)
+	if out.Len() != 0 {
+		t.Errorf("summary logged without events: %s", out)
+	}
+}
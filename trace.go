@@ -0,0 +1,120 @@
+package rz
+
+import (
+	"context"
+	"encoding/hex"
+	"math"
+	"strings"
+)
+
+type traceCtxKey struct{}
+
+// traceDecision is the sampling decision of a trace, applied by loggers.
+type traceDecision struct {
+	keep      bool
+	keepLevel LogLevel
+}
+
+// TraceSampler makes a single sampling decision per trace or request, by hashing its ID
+// against Ratio, so a trace keeps or drops all its events. The decision is stored in a
+// context by SampleCtx, and applied by the loggers returned by FromCtx for this context:
+// the events of kept traces bypass the loggers' samplers, and the events of dropped traces
+// below KeepLevel are dropped.
+type TraceSampler struct {
+	// Ratio is the ratio of kept traces, between 0 and 1.
+	Ratio float64
+	// KeepLevel is the level at or above which the events of dropped traces are kept
+	// anyway. Its zero value, DebugLevel, stands for the default ErrorLevel: use TraceLevel
+	// to keep the debug events, and Disabled to drop all the events.
+	KeepLevel LogLevel
+}
+
+// Sample returns true if the trace identified by traceID should be kept. The decision
+// only depends on traceID and Ratio.
+func (s TraceSampler) Sample(traceID string) bool {
+	if s.Ratio >= 1 {
+		return true
+	} else if s.Ratio <= 0 {
+		return false
+	}
+	return float64(fnvAddString(fnvOffset64, traceID))/math.MaxUint64 < s.Ratio
+}
+
+// SampleCtx returns a copy of ctx holding the sampling decision of the trace identified
+// by traceID. If ctx already holds a decision, ctx is returned unchanged.
+func (s TraceSampler) SampleCtx(ctx context.Context, traceID string) context.Context {
+	if _, ok := ctx.Value(traceCtxKey{}).(*traceDecision); ok {
+		return ctx
+	}
+	return s.withDecision(ctx, s.Sample(traceID))
+}
+
+// SampleTraceparentCtx is like SampleCtx, for the trace of a W3C traceparent header,
+// e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". Traces sampled
+// upstream, with the sampled flag set, are always kept. ctx is returned unchanged if
+// traceparent is invalid.
+func (s TraceSampler) SampleTraceparentCtx(ctx context.Context, traceparent string) context.Context {
+	if _, ok := ctx.Value(traceCtxKey{}).(*traceDecision); ok {
+		return ctx
+	}
+	traceID, sampled, ok := parseTraceparent(traceparent)
+	if !ok {
+		return ctx
+	}
+	return s.withDecision(ctx, sampled || s.Sample(traceID))
+}
+
+func (s TraceSampler) withDecision(ctx context.Context, keep bool) context.Context {
+	decision := &traceDecision{keep: keep, keepLevel: s.KeepLevel}
+	if decision.keepLevel == DebugLevel {
+		decision.keepLevel = ErrorLevel
+	}
+	ctx = context.WithValue(ctx, traceCtxKey{}, decision)
+	if l, ok := ctx.Value(ctxKey{}).(*Logger); ok {
+		ctx = withTraceLogger(ctx, l, decision)
+	}
+	return ctx
+}
+
+// withTraceLogger returns a copy of ctx associated with a copy of l applying decision,
+// so FromCtx doesn't have to copy the logger on each call.
+func withTraceLogger(ctx context.Context, l *Logger, decision *traceDecision) context.Context {
+	logger := l.With()
+	logger.trace = decision
+	return context.WithValue(ctx, ctxKey{}, &logger)
+}
+
+// TraceSampled returns the sampling decision held by ctx, and false if ctx holds none.
+func TraceSampled(ctx context.Context) (keep bool, ok bool) {
+	decision, ok := ctx.Value(traceCtxKey{}).(*traceDecision)
+	if !ok {
+		return false, false
+	}
+	return decision.keep, true
+}
+
+// parseTraceparent returns the trace ID and the sampled flag of a W3C traceparent header.
+func parseTraceparent(traceparent string) (traceID string, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 ||
+		len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", false, false
+	}
+	// version 00 has exactly 4 parts, future versions may add more
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", false, false
+	}
+	var flags [1]byte
+	for _, part := range parts[:3] {
+		if _, err := hex.DecodeString(part); err != nil {
+			return "", false, false
+		}
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return "", false, false
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", false, false
+	}
+	return strings.ToLower(parts[1]), flags[0]&1 == 1, true
+}
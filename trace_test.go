@@ -0,0 +1,110 @@
+package rz
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+)
+
+func TestTraceSampler(t *testing.T) {
+	sampler := TraceSampler{Ratio: 0.25}
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		traceID := strconv.Itoa(i)
+		keep := sampler.Sample(traceID)
+		if keep != sampler.Sample(traceID) {
+			t.Fatalf("inconsistent decision for %q", traceID)
+		}
+		if keep {
+			kept++
+		}
+	}
+	if kept < 2300 || kept > 2700 {
+		t.Errorf("kept %d traces on 10000 with a ratio of 0.25", kept)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		in      string
+		traceID string
+		sampled bool
+		ok      bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "4bf92f3577b34da6a3ce929d0e0e4736", false, true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03-future", "4bf92f3577b34da6a3ce929d0e0e4736", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01", "", false, false},
+		{"", "", false, false},
+	}
+	for _, tt := range tests {
+		traceID, sampled, ok := parseTraceparent(tt.in)
+		if traceID != tt.traceID || sampled != tt.sampled || ok != tt.ok {
+			t.Errorf("parseTraceparent(%q) = %q, %v, %v, want %q, %v, %v", tt.in, traceID, sampled, ok, tt.traceID, tt.sampled, tt.ok)
+		}
+	}
+}
+
+func TestTraceSamplerCtx(t *testing.T) {
+	out := &bytes.Buffer{}
+	// the logger's sampler drops everything but the first event
+	log := New(Writer(out), Fields(Timestamp(false)), Sampler(&SamplerBurst{Burst: 1, Period: 1 << 62}))
+	log.Info("burst")
+	out.Reset()
+	ctx := log.ToCtx(context.Background())
+	sampler := TraceSampler{Ratio: 0}
+
+	kept := sampler.SampleTraceparentCtx(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if keep, ok := TraceSampled(kept); !keep || !ok {
+		t.Errorf("TraceSampled() = %v, %v, want true, true", keep, ok)
+	}
+	FromCtx(kept).Info("kept")
+	child := FromCtx(kept).With(Fields(String("child", "yes")))
+	FromCtx(child.ToCtx(kept)).Info("kept")
+	other := New(Writer(out), Fields(Timestamp(false)), Sampler(&SamplerBasic{N: 1 << 30}))
+	FromCtx(other.ToCtx(kept)).Info("kept by other")
+
+	dropped := sampler.SampleCtx(ctx, "request-id")
+	if keep, ok := TraceSampled(dropped); keep || !ok {
+		t.Errorf("TraceSampled() = %v, %v, want false, true", keep, ok)
+	}
+	if sampler.SampleTraceparentCtx(dropped, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01") != dropped {
+		t.Error("the decision of a context should not be replaced")
+	}
+	FromCtx(dropped).Info("dropped")
+	FromCtx(dropped).Error("error")
+
+	want := `{"level":"info","message":"kept"}
+{"level":"info","child":"yes","message":"kept"}
+{"level":"info","message":"kept by other"}
+{"level":"error","message":"error"}
+`
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestTraceSamplerCtxLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New(Writer(out), Fields(Timestamp(false)))
+	sampler := TraceSampler{Ratio: 0}
+
+	// the decision is made before the logger is stored
+	ctx := log.ToCtx(sampler.SampleCtx(context.Background(), "request-id"))
+	logger := FromCtx(ctx)
+	if logger == &log || FromCtx(ctx) != logger {
+		t.Error("FromCtx should return the logger applying the decision stored by ToCtx")
+	}
+	if allocs := testing.AllocsPerRun(100, func() { FromCtx(ctx) }); allocs != 0 {
+		t.Errorf("FromCtx allocated %v times, want 0", allocs)
+	}
+	logger.Info("dropped")
+	logger.Error("error")
+	if got, want := out.String(), `{"level":"error","message":"error"}`+"\n"; got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+}
@@ -1,8 +1,14 @@
 package rz
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"os"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
 )
 
 // LevelWriter defines as interface a writer may implement in order
@@ -12,6 +18,20 @@ type LevelWriter interface {
 	WriteLevel(level LogLevel, p []byte) (n int, err error)
 }
 
+// LevelWriteCloser is a LevelWriter which must be closed to release its resources.
+type LevelWriteCloser interface {
+	LevelWriter
+	io.Closer
+}
+
+// eventWriter is implemented by writers reading the fields of the written event. Loggers
+// call writeEvent instead of WriteLevel, and fill in the event's entry if usesEntry returns
+// true.
+type eventWriter interface {
+	writeEvent(e *Event) (n int, err error)
+	usesEntry() bool
+}
+
 type levelWriterAdapter struct {
 	io.Writer
 }
@@ -98,3 +118,221 @@ func MultiLevelWriter(writers ...io.Writer) LevelWriter {
 	}
 	return multiLevelWriter{lwriters}
 }
+
+// DiodeWriterOption is used to configure a diode writer.
+type DiodeWriterOption func(w *diodeWriter)
+
+// DiodePollInterval makes the diode writer poll for new events every interval instead of
+// being woken up by each write. Polling saves a channel send per event on busy loggers.
+func DiodePollInterval(interval time.Duration) DiodeWriterOption {
+	return func(w *diodeWriter) {
+		w.pollInterval = interval
+	}
+}
+
+// DiodeAlert update the function called from the background goroutine with the number of
+// events overwritten before being written.
+func DiodeAlert(alert func(missed int)) DiodeWriterOption {
+	return func(w *diodeWriter) {
+		w.alert = alert
+	}
+}
+
+type diodeBucket struct {
+	seq   uint64
+	level LogLevel
+	buf   []byte
+}
+
+var diodeBucketPool = &sync.Pool{
+	New: func() interface{} {
+		return &diodeBucket{buf: make([]byte, 0, 500)}
+	},
+}
+
+func putDiodeBucket(b *diodeBucket) {
+	// see putEvent
+	const maxSize = 1 << 16 // 64KiB
+	if cap(b.buf) > maxSize {
+		return
+	}
+	diodeBucketPool.Put(b)
+}
+
+type diodeWriter struct {
+	// writeIndex and readIndex are first for 64-bit alignment of atomic operations on
+	// 32-bit platforms.
+
+	// writeIndex is the sequence of the last event written to the ring.
+	writeIndex uint64
+	// readIndex is the sequence of the next event to read, updated once the
+	// previous one has been written.
+	readIndex uint64
+
+	lw           LevelWriter
+	pollInterval time.Duration
+	alert        func(missed int)
+	ring         []unsafe.Pointer
+
+	wake   chan struct{}
+	done   chan struct{}
+	exit   chan struct{}
+	closed uint32
+}
+
+// DiodeWriter wraps w so that events are copied in a lock-free ring buffer of size
+// events and written on a background goroutine. Writers never block nor contend on
+// a lock: when the ring is full, the oldest events are overwritten and reported with
+// DiodeAlert. If w implements LevelWriter, its WriteLevel method is used.
+// Close must be called to write the remaining events before exiting.
+func DiodeWriter(w io.Writer, size int, options ...DiodeWriterOption) AsyncLevelWriter {
+	lw, ok := w.(LevelWriter)
+	if !ok {
+		lw = levelWriterAdapter{w}
+	}
+	if size <= 0 {
+		size = DefaultAsyncQueueSize
+	}
+	dw := &diodeWriter{
+		lw:         lw,
+		writeIndex: ^uint64(0),
+		ring:       make([]unsafe.Pointer, size),
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		exit:       make(chan struct{}),
+	}
+	for _, option := range options {
+		option(dw)
+	}
+	go dw.run()
+	return dw
+}
+
+// Write implements the io.Writer interface.
+func (w *diodeWriter) Write(p []byte) (n int, err error) {
+	return w.WriteLevel(NoLevel, p)
+}
+
+// WriteLevel implements the LevelWriter interface.
+func (w *diodeWriter) WriteLevel(level LogLevel, p []byte) (n int, err error) {
+	if atomic.LoadUint32(&w.closed) != 0 {
+		return 0, ErrAsyncWriterClosed
+	}
+	b := diodeBucketPool.Get().(*diodeBucket)
+	b.level = level
+	b.buf = append(b.buf[:0], p...)
+	size := uint64(len(w.ring))
+	for {
+		seq := atomic.AddUint64(&w.writeIndex, 1)
+		slot := &w.ring[seq%size]
+		old := atomic.LoadPointer(slot)
+		if old != nil && (*diodeBucket)(old).seq > seq-size {
+			// another writer lapped the ring and is using this slot
+			continue
+		}
+		b.seq = seq
+		if !atomic.CompareAndSwapPointer(slot, old, unsafe.Pointer(b)) {
+			continue
+		}
+		if old != nil {
+			// the overwritten event can't be read anymore
+			putDiodeBucket((*diodeBucket)(old))
+		}
+		break
+	}
+	if w.pollInterval <= 0 {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Flush implements the AsyncLevelWriter interface.
+func (w *diodeWriter) Flush(ctx context.Context) error {
+	interval := w.pollInterval
+	if interval <= 0 || interval > time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for atomic.LoadUint64(&w.readIndex) != atomic.LoadUint64(&w.writeIndex)+1 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close implements the AsyncLevelWriter interface.
+func (w *diodeWriter) Close() error {
+	if !atomic.CompareAndSwapUint32(&w.closed, 0, 1) {
+		return nil
+	}
+	close(w.done)
+	<-w.exit
+	return nil
+}
+
+func (w *diodeWriter) run() {
+	defer close(w.exit)
+
+	var tick <-chan time.Time
+	if w.pollInterval > 0 {
+		ticker := time.NewTicker(w.pollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		for w.next() {
+		}
+		select {
+		case <-w.wake:
+		case <-tick:
+		case <-w.done:
+			for w.next() {
+			}
+			return
+		}
+	}
+}
+
+// next writes the next event of the ring, if any.
+func (w *diodeWriter) next() bool {
+	readIndex := atomic.LoadUint64(&w.readIndex)
+	slot := &w.ring[readIndex%uint64(len(w.ring))]
+	p := atomic.SwapPointer(slot, nil)
+	if p == nil {
+		return false
+	}
+	b := (*diodeBucket)(p)
+	if b.seq < readIndex {
+		// stale event already accounted as missed
+		putDiodeBucket(b)
+		return false
+	}
+	if b.seq > readIndex {
+		if w.alert != nil {
+			w.alert(int(b.seq - readIndex))
+		}
+	}
+	if _, err := w.lw.WriteLevel(b.level, b.buf); err != nil {
+		handleWriteError(err)
+	}
+	atomic.StoreUint64(&w.readIndex, b.seq+1)
+	putDiodeBucket(b)
+	return true
+}
+
+// handleWriteError reports an error of a writer writing in the background.
+func handleWriteError(err error) {
+	if ErrorHandler != nil {
+		ErrorHandler(err)
+	} else {
+		fmt.Fprintf(os.Stderr, "rz: could not write event: %v\n", err)
+	}
+}
@@ -0,0 +1,275 @@
+package rz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// AsyncPolicy defines what an async writer does when its queue is full.
+type AsyncPolicy int
+
+const (
+	// AsyncBlock blocks the caller until there is room in the queue.
+	AsyncBlock AsyncPolicy = iota
+	// AsyncDropNewest drops the event being written.
+	AsyncDropNewest
+	// AsyncDropOldest drops the oldest queued event to make room for the new one.
+	AsyncDropOldest
+)
+
+const (
+	// DefaultAsyncQueueSize is the default number of events an async writer can queue.
+	DefaultAsyncQueueSize = 1024
+	// DefaultAsyncDropNoticeInterval is the default interval at which an async writer
+	// reports dropped events to its AsyncDropNotice function.
+	DefaultAsyncDropNoticeInterval = 10 * time.Second
+)
+
+// ErrAsyncWriterClosed is returned when writing to a closed async writer.
+var ErrAsyncWriterClosed = errors.New("rz: async writer closed")
+
+// AsyncLevelWriter is a LevelWriter writing events on a background goroutine.
+type AsyncLevelWriter interface {
+	LevelWriter
+	// Flush waits until all the queued events are written, or ctx is done.
+	Flush(ctx context.Context) error
+	// Close writes the queued events and stops the background goroutine. It does not
+	// close the wrapped writer.
+	Close() error
+}
+
+// AsyncWriterOption is used to configure an async writer.
+type AsyncWriterOption func(w *asyncWriter)
+
+// AsyncQueueSize update async writer's queue size.
+func AsyncQueueSize(size int) AsyncWriterOption {
+	return func(w *asyncWriter) {
+		if size > 0 {
+			w.queue = make([]asyncEvent, size)
+		}
+	}
+}
+
+// AsyncDropPolicy update async writer's policy when its queue is full.
+func AsyncDropPolicy(policy AsyncPolicy) AsyncWriterOption {
+	return func(w *asyncWriter) {
+		w.policy = policy
+	}
+}
+
+// AsyncDropNoticeInterval update the interval at which dropped events are reported to the
+// AsyncDropNotice function. An interval of 0 disables the notices.
+func AsyncDropNoticeInterval(interval time.Duration) AsyncWriterOption {
+	return func(w *asyncWriter) {
+		w.noticeInterval = interval
+	}
+}
+
+// AsyncDropNotice makes the async writer report dropped events by calling fn from the
+// background goroutine, with the number of events dropped since the last notice. Dropped
+// events are not reported by default: the writer doesn't know the encoding of the
+// events, so it can't write a notice itself. fn must not write to the async writer.
+func AsyncDropNotice(fn func(dropped uint64)) AsyncWriterOption {
+	return func(w *asyncWriter) {
+		w.notice = fn
+	}
+}
+
+type asyncEvent struct {
+	level LogLevel
+	buf   []byte
+}
+
+type asyncWriter struct {
+	lw             LevelWriter
+	policy         AsyncPolicy
+	noticeInterval time.Duration
+	notice         func(dropped uint64)
+
+	mu       sync.Mutex
+	notFull  *sync.Cond
+	queue    []asyncEvent
+	head     int
+	count    int
+	writing  bool
+	closed   bool
+	dropped  uint64
+	spare    []byte
+	flushing []chan struct{}
+
+	wake chan struct{}
+	done chan struct{}
+	exit chan struct{}
+}
+
+// AsyncWriter wraps w so that events are copied in a bounded queue and written on a
+// background goroutine, so a slow w doesn't stall the logging goroutines. If w
+// implements LevelWriter, its WriteLevel method is used.
+// Close must be called to write the queued events before exiting.
+func AsyncWriter(w io.Writer, options ...AsyncWriterOption) AsyncLevelWriter {
+	lw, ok := w.(LevelWriter)
+	if !ok {
+		lw = levelWriterAdapter{w}
+	}
+	aw := &asyncWriter{
+		lw:             lw,
+		policy:         AsyncBlock,
+		noticeInterval: DefaultAsyncDropNoticeInterval,
+		queue:          make([]asyncEvent, DefaultAsyncQueueSize),
+		wake:           make(chan struct{}, 1),
+		done:           make(chan struct{}),
+		exit:           make(chan struct{}),
+	}
+	aw.notFull = sync.NewCond(&aw.mu)
+	for _, option := range options {
+		option(aw)
+	}
+	go aw.run()
+	return aw
+}
+
+// Write implements the io.Writer interface.
+func (w *asyncWriter) Write(p []byte) (n int, err error) {
+	return w.WriteLevel(NoLevel, p)
+}
+
+// WriteLevel implements the LevelWriter interface.
+func (w *asyncWriter) WriteLevel(level LogLevel, p []byte) (n int, err error) {
+	w.mu.Lock()
+	for !w.closed && w.count == len(w.queue) && w.policy == AsyncBlock {
+		w.notFull.Wait()
+	}
+	if w.closed {
+		w.mu.Unlock()
+		return 0, ErrAsyncWriterClosed
+	}
+	if w.count == len(w.queue) {
+		w.dropped++
+		if w.policy == AsyncDropNewest {
+			w.mu.Unlock()
+			return len(p), nil
+		}
+		// AsyncDropOldest: the new event takes the place of the oldest one
+		w.head = (w.head + 1) % len(w.queue)
+		w.count--
+	}
+	slot := &w.queue[(w.head+w.count)%len(w.queue)]
+	slot.level = level
+	slot.buf = append(slot.buf[:0], p...)
+	w.count++
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// Flush implements the AsyncLevelWriter interface. It returns ErrAsyncWriterClosed if the
+// writer is closed.
+func (w *asyncWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrAsyncWriterClosed
+	}
+	if w.count == 0 && !w.writing {
+		w.mu.Unlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	w.flushing = append(w.flushing, flushed)
+	w.mu.Unlock()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close implements the AsyncLevelWriter interface.
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	close(w.done)
+	<-w.exit
+	return nil
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.exit)
+
+	var tick <-chan time.Time
+	if w.noticeInterval > 0 && w.notice != nil {
+		ticker := time.NewTicker(w.noticeInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.wake:
+			w.drain()
+		case <-tick:
+			w.reportDropped()
+		case <-w.done:
+			w.drain()
+			w.reportDropped()
+			return
+		}
+	}
+}
+
+// drain writes the queued events until the queue is empty.
+func (w *asyncWriter) drain() {
+	w.mu.Lock()
+	for w.count > 0 {
+		slot := &w.queue[w.head]
+		level, buf := slot.level, slot.buf
+		// hand the slot a spare buffer so it can be reused while buf is written
+		slot.buf = w.spare
+		w.spare = nil
+		w.head = (w.head + 1) % len(w.queue)
+		w.count--
+		w.writing = true
+		w.notFull.Signal()
+		w.mu.Unlock()
+
+		if _, err := w.lw.WriteLevel(level, buf); err != nil {
+			handleWriteError(err)
+		}
+
+		w.mu.Lock()
+		w.writing = false
+		w.spare = buf[:0]
+	}
+	for _, flushed := range w.flushing {
+		close(flushed)
+	}
+	w.flushing = nil
+	w.mu.Unlock()
+}
+
+func (w *asyncWriter) reportDropped() {
+	w.mu.Lock()
+	dropped := w.dropped
+	w.dropped = 0
+	w.mu.Unlock()
+
+	if dropped > 0 && w.notice != nil {
+		w.notice(dropped)
+	}
+}
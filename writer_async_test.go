@@ -0,0 +1,157 @@
+package rz
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter records events once release is closed.
+type blockingWriter struct {
+	mu      sync.Mutex
+	started chan struct{}
+	release chan struct{}
+	events  []string
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{started: make(chan struct{}, 100), release: make(chan struct{})}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.started <- struct{}{}
+	<-w.release
+	w.mu.Lock()
+	w.events = append(w.events, string(p))
+	w.mu.Unlock()
+	return len(p), nil
+}
+
+func TestAsyncWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := AsyncWriter(out)
+	log := New(Writer(w), Fields(Timestamp(false)))
+	for i := 0; i < 3; i++ {
+		log.Info("hello", Int("i", i))
+	}
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"level":"info","i":0,"message":"hello"}` + "\n" +
+		`{"level":"info","i":1,"message":"hello"}` + "\n" +
+		`{"level":"info","i":2,"message":"hello"}` + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("invalid log output:\ngot:  %v\nwant: %v", got, want)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("closed\n")); err != ErrAsyncWriterClosed {
+		t.Errorf("Write() after Close() error = %v, want %v", err, ErrAsyncWriterClosed)
+	}
+	if err := w.Flush(context.Background()); err != ErrAsyncWriterClosed {
+		t.Errorf("Flush() after Close() error = %v, want %v", err, ErrAsyncWriterClosed)
+	}
+}
+
+func TestAsyncWriterDropPolicy(t *testing.T) {
+	tests := []struct {
+		policy AsyncPolicy
+		want   []string
+	}{
+		{AsyncDropNewest, []string{"0", "1", "2"}},
+		{AsyncDropOldest, []string{"0", "3", "4"}},
+	}
+	for _, tt := range tests {
+		bw := newBlockingWriter()
+		var dropped uint64
+		w := AsyncWriter(bw, AsyncQueueSize(2), AsyncDropPolicy(tt.policy),
+			AsyncDropNotice(func(n uint64) { dropped += n }))
+		w.Write([]byte("0"))
+		// wait for "0" to be written so the queue is empty
+		<-bw.started
+		for _, p := range []string{"1", "2", "3", "4"} {
+			w.Write([]byte(p))
+		}
+		close(bw.release)
+		w.Close()
+		if got := bw.events; strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("policy %d: events = %v, want %v", tt.policy, got, tt.want)
+		}
+		if dropped != 2 {
+			t.Errorf("policy %d: dropped = %d, want 2", tt.policy, dropped)
+		}
+	}
+}
+
+func TestAsyncWriterBlock(t *testing.T) {
+	bw := newBlockingWriter()
+	w := AsyncWriter(bw, AsyncQueueSize(1))
+	w.Write([]byte("0"))
+	<-bw.started
+	w.Write([]byte("1"))
+
+	written := make(chan struct{})
+	go func() {
+		w.Write([]byte("2"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("Write() did not block on a full queue")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(bw.release)
+	<-written
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := w.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(bw.events, ","), "0,1,2"; got != want {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	w.Close()
+}
+
+func TestAsyncWriterDropNotice(t *testing.T) {
+	for _, notice := range []bool{false, true} {
+		bw := newBlockingWriter()
+		var notices []uint64
+		options := []AsyncWriterOption{AsyncQueueSize(1), AsyncDropPolicy(AsyncDropNewest)}
+		if notice {
+			options = append(options, AsyncDropNotice(func(n uint64) { notices = append(notices, n) }))
+		}
+		w := AsyncWriter(bw, options...)
+		w.Write([]byte("0"))
+		<-bw.started
+		w.Write([]byte("1"))
+		w.Write([]byte("2"))
+		close(bw.release)
+		w.Close()
+		// the notice must not be written to the events' writer, whose encoding is unknown
+		if got, want := strings.Join(bw.events, ","), "0,1"; got != want {
+			t.Errorf("notice %v: events = %q, want %q", notice, got, want)
+		}
+		if notice && (len(notices) != 1 || notices[0] != 1) {
+			t.Errorf("notices = %v, want [1]", notices)
+		}
+	}
+}
+
+func TestAsyncWriterFlushTimeout(t *testing.T) {
+	bw := newBlockingWriter()
+	w := AsyncWriter(bw)
+	w.Write([]byte("0"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := w.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Flush() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(bw.release)
+	w.Close()
+}
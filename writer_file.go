@@ -0,0 +1,440 @@
+package rz
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+)
+
+// RotatingLevelWriter is a LevelWriter writing to a file which is rotated.
+type RotatingLevelWriter interface {
+	LevelWriter
+	// Rotate closes the current file, renames it as a backup and opens a new one.
+	Rotate() error
+	// Reopen closes and reopens the file, e.g. after it has been moved by an external tool.
+	Reopen() error
+	// Close closes the file and waits for the background compression and cleanup.
+	Close() error
+}
+
+// FileWriterOption is used to configure a file writer.
+type FileWriterOption func(w *fileWriter)
+
+// FileMaxSize rotates the file before it grows larger than maxSize bytes.
+// A maxSize of 0 disables size based rotation.
+func FileMaxSize(maxSize int64) FileWriterOption {
+	return func(w *fileWriter) {
+		w.maxSize = maxSize
+	}
+}
+
+// FileRotateEvery rotates the file every interval, aligned on the wall clock: an
+// interval of 24 hours rotates the file at midnight UTC.
+// An interval of 0 disables time based rotation.
+func FileRotateEvery(interval time.Duration) FileWriterOption {
+	return func(w *fileWriter) {
+		w.interval = interval
+	}
+}
+
+// FileMaxBackups update the number of backups to keep. A maxBackups of 0 keeps all of them.
+func FileMaxBackups(maxBackups int) FileWriterOption {
+	return func(w *fileWriter) {
+		w.maxBackups = maxBackups
+	}
+}
+
+// FileMaxAge removes the backups older than maxAge. A maxAge of 0 keeps all of them.
+func FileMaxAge(maxAge time.Duration) FileWriterOption {
+	return func(w *fileWriter) {
+		w.maxAge = maxAge
+	}
+}
+
+// FileCompress enable/disable gzip compression of the backups, in the background.
+func FileCompress(compress bool) FileWriterOption {
+	return func(w *fileWriter) {
+		w.compress = compress
+	}
+}
+
+// FileReopenOnSIGHUP enable/disable reopening the file when the process receives SIGHUP.
+// It is enabled by default.
+func FileReopenOnSIGHUP(reopen bool) FileWriterOption {
+	return func(w *fileWriter) {
+		w.reopenOnSIGHUP = reopen
+	}
+}
+
+// FileClock update file writer's clock, used for time based rotation and backup names.
+func FileClock(now func() time.Time) FileWriterOption {
+	return func(w *fileWriter) {
+		w.now = now
+	}
+}
+
+type fileWriter struct {
+	filename       string
+	maxSize        int64
+	interval       time.Duration
+	maxBackups     int
+	maxAge         time.Duration
+	compress       bool
+	reopenOnSIGHUP bool
+	now            func() time.Time
+
+	mu           sync.Mutex
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+
+	mill     chan struct{}
+	signals  chan os.Signal
+	done     chan struct{}
+	wg       sync.WaitGroup
+	closeErr error
+	closed   bool
+}
+
+// FileWriter creates a writer appending events to filename, rotating it according to
+// options. Each event is written to a single file: rotation happens between events.
+// Backups are named after filename with the rotation time inserted before the
+// extension, e.g. app-2006-01-02T15-04-05.000.log, followed by a counter if a backup
+// rotated in the same millisecond exists.
+func FileWriter(filename string, options ...FileWriterOption) (RotatingLevelWriter, error) {
+	w := &fileWriter{
+		filename:       filename,
+		reopenOnSIGHUP: true,
+		now:            time.Now,
+		mill:           make(chan struct{}, 1),
+		done:           make(chan struct{}),
+	}
+	for _, option := range options {
+		option(w)
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	w.scheduleRotation()
+
+	w.wg.Add(1)
+	go w.runMill()
+	if w.reopenOnSIGHUP {
+		w.signals = make(chan os.Signal, 1)
+		signal.Notify(w.signals, syscall.SIGHUP)
+		w.wg.Add(1)
+		go w.runSignals()
+	}
+	// clean up the backups left by a previous run
+	w.millBackups()
+	return w, nil
+}
+
+// Write implements the io.Writer interface.
+func (w *fileWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.shouldRotate(int64(len(p))) {
+		if err = w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// WriteLevel implements the LevelWriter interface.
+func (w *fileWriter) WriteLevel(level LogLevel, p []byte) (n int, err error) {
+	return w.Write(p)
+}
+
+// Rotate implements the RotatingLevelWriter interface.
+func (w *fileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Reopen implements the RotatingLevelWriter interface.
+func (w *fileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	old := w.file
+	if err := w.open(); err != nil {
+		return err
+	}
+	return old.Close()
+}
+
+// Close implements the RotatingLevelWriter interface.
+func (w *fileWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return w.closeErr
+	}
+	w.closed = true
+	w.closeErr = w.file.Close()
+	w.mu.Unlock()
+
+	if w.signals != nil {
+		signal.Stop(w.signals)
+	}
+	close(w.done)
+	w.wg.Wait()
+	return w.closeErr
+}
+
+func (w *fileWriter) shouldRotate(n int64) bool {
+	if w.maxSize > 0 && w.size > 0 && w.size+n > w.maxSize {
+		return true
+	}
+	return w.interval > 0 && !w.now().Before(w.nextRotation)
+}
+
+func (w *fileWriter) scheduleRotation() {
+	if w.interval > 0 {
+		w.nextRotation = w.now().Truncate(w.interval).Add(w.interval)
+	}
+}
+
+// open opens or creates the file, replacing w.file without closing it. w.mu must be held.
+func (w *fileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate renames the file as a backup and opens a new one. The old file is closed once the
+// new one is open, so events keep being written to it if the new one can't be opened.
+// w.mu must be held.
+func (w *fileWriter) rotate() error {
+	if err := os.Rename(w.filename, w.backupName(w.now())); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	old := w.file
+	if err := w.open(); err != nil {
+		return err
+	}
+	w.scheduleRotation()
+	w.millBackups()
+	return old.Close()
+}
+
+// backupName returns the name of a backup rotated at t which doesn't exist yet, compressed
+// or not: backups rotated within the same millisecond are suffixed with a counter, e.g.
+// app-2006-01-02T15-04-05.000-1.log.
+func (w *fileWriter) backupName(t time.Time) string {
+	dir, prefix, ext := w.backupParts()
+	stamp := prefix + t.UTC().Format(backupTimeFormat)
+	for i := 0; ; i++ {
+		name := stamp
+		if i > 0 {
+			name += "-" + strconv.Itoa(i)
+		}
+		name = filepath.Join(dir, name+ext)
+		if !fileExists(name) && !fileExists(name+compressSuffix) {
+			return name
+		}
+	}
+}
+
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+	return !errors.Is(err, os.ErrNotExist)
+}
+
+// parseBackupStamp parses the rotation time and counter of a backup name stripped of its
+// prefix and extension.
+func parseBackupStamp(stamp string) (t time.Time, counter int, err error) {
+	if n := len(backupTimeFormat); len(stamp) > n && stamp[n] == '-' {
+		if counter, err = strconv.Atoi(stamp[n+1:]); err != nil {
+			return t, 0, err
+		}
+		stamp = stamp[:n]
+	}
+	t, err = time.Parse(backupTimeFormat, stamp)
+	return t, counter, err
+}
+
+func (w *fileWriter) backupParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(w.filename)
+	name := filepath.Base(w.filename)
+	ext = filepath.Ext(name)
+	prefix = strings.TrimSuffix(name, ext) + "-"
+	return
+}
+
+func (w *fileWriter) millBackups() {
+	select {
+	case w.mill <- struct{}{}:
+	default:
+	}
+}
+
+func (w *fileWriter) runMill() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.mill:
+			w.millOnce()
+		case <-w.done:
+			// finish the pending work before exiting
+			select {
+			case <-w.mill:
+				w.millOnce()
+			default:
+			}
+			return
+		}
+	}
+}
+
+func (w *fileWriter) millOnce() {
+	if err := w.cleanBackups(); err != nil {
+		handleWriteError(err)
+	}
+}
+
+func (w *fileWriter) runSignals() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.signals:
+			if err := w.Reopen(); err != nil {
+				handleWriteError(err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+type backupFile struct {
+	path      string
+	timestamp time.Time
+	counter   int
+}
+
+// cleanBackups compresses the backups and removes the ones exceeding maxBackups or maxAge.
+func (w *fileWriter) cleanBackups() error {
+	dir, prefix, ext := w.backupParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	backups := []backupFile{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		timestamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressSuffix)
+		if !strings.HasSuffix(timestamp, ext) {
+			continue
+		}
+		t, counter, err := parseBackupStamp(strings.TrimSuffix(timestamp, ext))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), timestamp: t, counter: counter})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].timestamp.Equal(backups[j].timestamp) {
+			return backups[i].counter > backups[j].counter
+		}
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+
+	var errs []string
+	cutoff := w.now().Add(-w.maxAge)
+	for i, backup := range backups {
+		if (w.maxBackups > 0 && i >= w.maxBackups) || (w.maxAge > 0 && backup.timestamp.Before(cutoff)) {
+			if err := os.Remove(backup.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err.Error())
+			}
+			continue
+		}
+		if w.compress && !strings.HasSuffix(backup.path, compressSuffix) {
+			if err := compressFile(backup.path); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("rz: cleaning log backups: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// compressFile gzips src to src.gz and removes src.
+func compressFile(src string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	dst := src + compressSuffix
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(dst)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
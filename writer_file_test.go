@@ -0,0 +1,228 @@
+package rz
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func readDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b []byte
+		if strings.HasSuffix(path, compressSuffix) {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err = ioutil.ReadAll(gz)
+		} else {
+			b, err = ioutil.ReadAll(f)
+		}
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = string(b)
+	}
+	return files
+}
+
+func fileNames(files map[string]string) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func TestFileWriterMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	w, err := FileWriter(filepath.Join(dir, "app.log"), FileMaxSize(10), FileClock(clock.Now), FileReopenOnSIGHUP(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddddddddddd\n", "e\n"} {
+		w.Write([]byte(p))
+		clock.Add(time.Second)
+	}
+	w.Close()
+
+	files := readDir(t, dir)
+	want := map[string]string{
+		"app-2020-01-02T03-04-07.000.log": "aaaa\nbbbb\n",
+		"app-2020-01-02T03-04-08.000.log": "cccc\n",
+		"app-2020-01-02T03-04-09.000.log": "dddddddddddd\n",
+		"app.log":                         "e\n",
+	}
+	if fileNames(files) != fileNames(want) {
+		t.Fatalf("files = %v, want %v", fileNames(files), fileNames(want))
+	}
+	for name, content := range want {
+		if files[name] != content {
+			t.Errorf("%s = %q, want %q", name, files[name], content)
+		}
+	}
+}
+
+func TestFileWriterSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	w, err := FileWriter(filepath.Join(dir, "app.log"), FileMaxBackups(2), FileClock(clock.Now),
+		FileReopenOnSIGHUP(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"a\n", "b\n", "c\n"} {
+		w.Write([]byte(p))
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Write([]byte("d\n"))
+	w.Close()
+
+	// the oldest backup is removed, the newest ones have the highest counters
+	files := readDir(t, dir)
+	want := map[string]string{
+		"app-2020-01-02T03-04-05.000-1.log": "b\n",
+		"app-2020-01-02T03-04-05.000-2.log": "c\n",
+		"app.log":                           "d\n",
+	}
+	if fileNames(files) != fileNames(want) {
+		t.Fatalf("files = %v, want %v", fileNames(files), fileNames(want))
+	}
+	for name, content := range want {
+		if files[name] != content {
+			t.Errorf("%s = %q, want %q", name, files[name], content)
+		}
+	}
+}
+
+func TestFileWriterRotateEvery(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2020, 1, 2, 23, 59, 0, 0, time.UTC)}
+	w, err := FileWriter(filepath.Join(dir, "app.log"), FileRotateEvery(24*time.Hour), FileClock(clock.Now),
+		FileReopenOnSIGHUP(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("day 1\n"))
+	clock.Add(30 * time.Second)
+	w.Write([]byte("day 1\n"))
+	clock.Add(30 * time.Second)
+	w.Write([]byte("day 2\n"))
+	clock.Add(24 * time.Hour)
+	w.Write([]byte("day 3\n"))
+	w.Close()
+
+	files := readDir(t, dir)
+	want := map[string]string{
+		"app-2020-01-03T00-00-00.000.log": "day 1\nday 1\n",
+		"app-2020-01-04T00-00-00.000.log": "day 2\n",
+		"app.log":                         "day 3\n",
+	}
+	if fileNames(files) != fileNames(want) {
+		t.Fatalf("files = %v, want %v", fileNames(files), fileNames(want))
+	}
+	for name, content := range want {
+		if files[name] != content {
+			t.Errorf("%s = %q, want %q", name, files[name], content)
+		}
+	}
+}
+
+func TestFileWriterBackups(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)}
+	// an old backup, removed because of max age
+	old := filepath.Join(dir, "app-2019-01-01T00-00-00.000.log")
+	if err := ioutil.WriteFile(old, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := FileWriter(filepath.Join(dir, "app.log"), FileMaxBackups(2), FileMaxAge(24*time.Hour),
+		FileCompress(true), FileClock(clock.Now), FileReopenOnSIGHUP(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"1\n", "2\n", "3\n", "4\n"} {
+		w.Write([]byte(p))
+		clock.Add(time.Minute)
+		w.Rotate()
+	}
+	w.Close()
+
+	files := readDir(t, dir)
+	want := map[string]string{
+		"app-2020-01-02T00-03-00.000.log.gz": "3\n",
+		"app-2020-01-02T00-04-00.000.log.gz": "4\n",
+		"app.log":                            "",
+	}
+	if fileNames(files) != fileNames(want) {
+		t.Fatalf("files = %v, want %v", fileNames(files), fileNames(want))
+	}
+	for name, content := range want {
+		if files[name] != content {
+			t.Errorf("%s = %q, want %q", name, files[name], content)
+		}
+	}
+}
+
+func TestFileWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	w, err := FileWriter(filename, FileReopenOnSIGHUP(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("before\n"))
+	// simulate an external logrotate
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("after\n"))
+	w.Close()
+
+	files := readDir(t, dir)
+	if files["app.log.1"] != "before\n" || files["app.log"] != "after\n" {
+		t.Errorf("files = %v", files)
+	}
+}
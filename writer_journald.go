@@ -0,0 +1,356 @@
+//go:build linux
+// +build linux
+
+package rz
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// DefaultJournaldSocket is the path of the journald native protocol socket.
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// JournaldWriterOption is used to configure a journald writer.
+type JournaldWriterOption func(w *journaldWriter)
+
+// JournaldSocket update journald writer's socket path.
+func JournaldSocket(path string) JournaldWriterOption {
+	return func(w *journaldWriter) {
+		w.socket = path
+	}
+}
+
+// JournaldIdentifier update the SYSLOG_IDENTIFIER of the entries. It defaults to the name
+// of the program.
+func JournaldIdentifier(identifier string) JournaldWriterOption {
+	return func(w *journaldWriter) {
+		w.identifier = identifier
+	}
+}
+
+// JournaldFieldNames update the names of the level, message and caller fields of the
+// events written through another writer, e.g. an AsyncWriter, if the logger doesn't use
+// the default ones.
+func JournaldFieldNames(levelFieldName, messageFieldName, callerFieldName string) JournaldWriterOption {
+	return func(w *journaldWriter) {
+		w.levelFieldName = levelFieldName
+		w.messageFieldName = messageFieldName
+		w.callerFieldName = callerFieldName
+	}
+}
+
+type journaldWriter struct {
+	socket           string
+	identifier       string
+	levelFieldName   string
+	messageFieldName string
+	callerFieldName  string
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+	addr *net.UnixAddr
+	buf  bytes.Buffer
+}
+
+// JournaldWriter creates a writer sending events to systemd-journald using its native
+// protocol: the level is sent as PRIORITY, the message as MESSAGE, the caller as
+// CODE_FILE and CODE_LINE and the other top-level fields, in order, with their name
+// upper-cased, e.g. "user_id" as USER_ID, and prefixed with FIELD_ if it's one of the
+// previous names, e.g. "priority" as FIELD_PRIORITY. The fields are read from the events whatever
+// their encoding, unless the journald writer is wrapped by another writer, e.g. an
+// AsyncWriter: the events must then be encoded in JSON.
+// Entries too large for a datagram are sent through a sealed memfd, or an unlinked file
+// in /dev/shm if memfd is unavailable.
+func JournaldWriter(options ...JournaldWriterOption) (LevelWriteCloser, error) {
+	w := &journaldWriter{
+		socket:           DefaultJournaldSocket,
+		identifier:       filepath.Base(os.Args[0]),
+		levelFieldName:   DefaultLevelFieldName,
+		messageFieldName: DefaultMessageFieldName,
+		callerFieldName:  DefaultCallerFieldName,
+	}
+	for _, option := range options {
+		option(w)
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	w.conn = conn
+	w.addr = &net.UnixAddr{Name: w.socket, Net: "unixgram"}
+	return w, nil
+}
+
+// Write implements the io.Writer interface.
+func (w *journaldWriter) Write(p []byte) (n int, err error) {
+	return w.WriteLevel(NoLevel, p)
+}
+
+// WriteLevel implements the LevelWriter interface.
+func (w *journaldWriter) WriteLevel(level LogLevel, p []byte) (n int, err error) {
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	if t, err := d.Token(); err != nil {
+		return 0, err
+	} else if t != json.Delim('{') {
+		return 0, errors.New("rz: journald writer: event is not a JSON object")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Reset()
+	w.appendHeader(level)
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return 0, err
+		}
+		key, _ := t.(string)
+		var value interface{}
+		if err = d.Decode(&value); err != nil {
+			return 0, err
+		}
+		switch key {
+		case w.levelFieldName:
+		case w.messageFieldName:
+			appendJournaldField(&w.buf, "MESSAGE", entryValueText(value, ""))
+		case w.callerFieldName:
+			w.appendCaller(entryValueText(value, ""))
+		default:
+			w.appendField(key, entryValueText(value, ""))
+		}
+	}
+
+	if err = w.send(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *journaldWriter) writeEvent(e *Event) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Reset()
+	w.appendHeader(e.level)
+	e.Range(func(key string, value interface{}) bool {
+		w.appendField(key, entryValueText(value, e.timeFieldFormat))
+		return true
+	})
+	if !e.entry.Timestamp.IsZero() {
+		w.appendField(e.timestampFieldName, formatTime(e.entry.Timestamp, e.timeFieldFormat))
+	}
+	if e.entry.Message != "" {
+		appendJournaldField(&w.buf, "MESSAGE", e.entry.Message)
+	}
+	if e.entry.Caller != "" {
+		w.appendCaller(e.entry.Caller)
+	}
+
+	if err = w.send(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(e.buf), nil
+}
+
+func (w *journaldWriter) usesEntry() bool {
+	return true
+}
+
+func (w *journaldWriter) appendHeader(level LogLevel) {
+	appendJournaldField(&w.buf, "PRIORITY", strconv.Itoa(syslogSeverity(level)))
+	if w.identifier != "" {
+		appendJournaldField(&w.buf, "SYSLOG_IDENTIFIER", w.identifier)
+	}
+}
+
+func (w *journaldWriter) appendCaller(caller string) {
+	if i := strings.LastIndexByte(caller, ':'); i >= 0 {
+		appendJournaldField(&w.buf, "CODE_FILE", caller[:i])
+		appendJournaldField(&w.buf, "CODE_LINE", caller[i+1:])
+		return
+	}
+	w.appendField(w.callerFieldName, caller)
+}
+
+func (w *journaldWriter) appendField(key, value string) {
+	if name := journaldFieldName(key); name != "" {
+		appendJournaldField(&w.buf, name, value)
+	}
+}
+
+// Close implements the io.Closer interface.
+func (w *journaldWriter) Close() error {
+	return w.conn.Close()
+}
+
+func (w *journaldWriter) send(entry []byte) error {
+	_, _, err := w.conn.WriteMsgUnix(entry, nil, w.addr)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	// the entry is too large for a datagram: send the file descriptor of a file holding it
+	// instead.
+	file, err := journaldEntryFile(entry)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, _, err = w.conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), w.addr)
+	return err
+}
+
+// memfd_create(2) and fcntl(2) file sealing values, missing from the syscall package.
+const (
+	mfdCloexec      = 0x1
+	mfdAllowSealing = 0x2
+	fcntlAddSeals   = 1024 + 9
+	sealSeal        = 0x1
+	sealShrink      = 0x2
+	sealGrow        = 0x4
+	sealWrite       = 0x8
+)
+
+// sysMemfdCreate is the memfd_create(2) syscall number of the architecture, 0 if unknown.
+var sysMemfdCreate = map[string]uintptr{
+	"386":      356,
+	"amd64":    319,
+	"arm":      385,
+	"arm64":    279,
+	"loong64":  279,
+	"mips":     4354,
+	"mipsle":   4354,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+	"s390x":    350,
+}[runtime.GOARCH]
+
+// memfdCreate creates an anonymous file which can be sealed.
+func memfdCreate(name string) (int, error) {
+	if sysMemfdCreate == 0 {
+		return -1, syscall.ENOSYS
+	}
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return -1, err
+	}
+	fd, _, errno := syscall.Syscall(sysMemfdCreate, uintptr(unsafe.Pointer(p)), mfdCloexec|mfdAllowSealing, 0)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
+
+// journaldEntryFile returns a sealed memfd holding entry, or an unlinked file in /dev/shm
+// if memfd is unavailable.
+func journaldEntryFile(entry []byte) (*os.File, error) {
+	fd, err := memfdCreate("rz-journald")
+	if err != nil {
+		return journaldTempFile(entry)
+	}
+	file := os.NewFile(uintptr(fd), "rz-journald")
+	if _, err = file.Write(entry); err == nil {
+		_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, file.Fd(), fcntlAddSeals,
+			sealShrink|sealGrow|sealWrite|sealSeal)
+		if errno != 0 {
+			err = errno
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func journaldTempFile(entry []byte) (*os.File, error) {
+	file, err := ioutil.TempFile("/dev/shm", "rz-journald-")
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Remove(file.Name()); err == nil {
+		_, err = file.Write(entry)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// appendJournaldField appends a field, using the binary form if value contains a newline.
+func appendJournaldField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if strings.IndexByte(value, '\n') < 0 {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journaldReservedFields are the journal fields set by the writer.
+var journaldReservedFields = map[string]bool{
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"MESSAGE":           true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+}
+
+// journaldFieldName converts key to a valid journal field name: upper-cased letters,
+// digits and underscores, starting with a letter. The names of the fields set by the
+// writer are prefixed with FIELD_, so they don't override them.
+func journaldFieldName(key string) string {
+	name := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			name = append(name, c-'a'+'A')
+		case c >= 'A' && c <= 'Z':
+			name = append(name, c)
+		case len(name) == 0:
+			// skip leading digits and underscores
+		case c >= '0' && c <= '9':
+			name = append(name, c)
+		default:
+			name = append(name, '_')
+		}
+	}
+	// journal field names are limited to 64 characters
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	if journaldReservedFields[string(name)] {
+		return "FIELD_" + string(name)
+	}
+	return string(name)
+}
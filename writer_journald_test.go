@@ -0,0 +1,166 @@
+//go:build linux
+// +build linux
+
+package rz
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/skerkour/rz/logfmt"
+)
+
+// readJournaldEntry reads an entry sent to conn, following the file descriptor of large
+// entries, which must be a sealed memfd. The field names are returned in order.
+func readJournaldEntry(t *testing.T, conn *net.UnixConn) (map[string]string, []string) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	p := make([]byte, 1<<16)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(p, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = p[:n]
+	if oobn > 0 {
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			t.Fatal(err)
+		}
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		file := os.NewFile(uintptr(fds[0]), "entry")
+		defer file.Close()
+		const fcntlGetSeals = 1024 + 10
+		seals, _, errno := syscall.Syscall(syscall.SYS_FCNTL, file.Fd(), fcntlGetSeals, 0)
+		if errno != 0 {
+			t.Fatal(errno)
+		}
+		if want := uintptr(sealShrink | sealGrow | sealWrite | sealSeal); seals != want {
+			t.Errorf("seals = %#x, want %#x", seals, want)
+		}
+		file.Seek(0, io.SeekStart)
+		if p, err = ioutil.ReadAll(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entry := map[string]string{}
+	var names []string
+	r := bufio.NewReader(bytes.NewReader(p))
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			return entry, names
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if i := strings.IndexByte(line, '='); i >= 0 {
+			entry[line[:i]] = line[i+1:]
+			names = append(names, line[:i])
+			continue
+		}
+		names = append(names, line)
+		var size uint64
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			t.Fatal(err)
+		}
+		value := make([]byte, size+1)
+		if _, err := io.ReadFull(r, value); err != nil {
+			t.Fatal(err)
+		}
+		entry[line] = string(value[:size])
+	}
+}
+
+func TestJournaldWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	w, err := JournaldWriter(JournaldSocket(path), JournaldIdentifier("app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	log := New(Writer(w), Fields(Timestamp(false)))
+
+	log.Warn("hello\nworld", Int("user_id", 42), String("_trusted", "no"), Dict("req", log.NewDict(String("path", "/"))))
+	got, names := readJournaldEntry(t, conn)
+	want := map[string]string{
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "app",
+		"MESSAGE":           "hello\nworld",
+		"USER_ID":           "42",
+		"TRUSTED":           "no",
+		"REQ":               `{"path":"/"}`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entry:\ngot:  %q\nwant: %q", got, want)
+	}
+	if got, want := strings.Join(names, ","), "PRIORITY,SYSLOG_IDENTIFIER,USER_ID,TRUSTED,REQ,MESSAGE"; got != want {
+		t.Errorf("field names = %s, want %s", got, want)
+	}
+
+	// the fields named like the ones set by the writer don't override them
+	log.Info("hello", String("priority", "high"), String("message", "dup"), String("Syslog_Identifier", "other"),
+		String("code_line", "1"))
+	got, names = readJournaldEntry(t, conn)
+	if got["PRIORITY"] != "6" || got["MESSAGE"] != "hello" || got["SYSLOG_IDENTIFIER"] != "app" ||
+		got["FIELD_PRIORITY"] != "high" || got["FIELD_MESSAGE"] != "dup" {
+		t.Errorf("entry = %q, want reserved fields prefixed with FIELD_", got)
+	}
+	if got, want := strings.Join(names, ","), "PRIORITY,SYSLOG_IDENTIFIER,FIELD_PRIORITY,FIELD_MESSAGE,FIELD_SYSLOG_IDENTIFIER,FIELD_CODE_LINE,MESSAGE"; got != want {
+		t.Errorf("field names = %s, want %s", got, want)
+	}
+
+	// the fields are read from the event whatever its encoding, or decoded from JSON
+	for _, w := range []io.Writer{w, AsyncWriter(w)} {
+		log := New(Writer(w), Fields(Timestamp(false)))
+		if _, ok := w.(AsyncLevelWriter); !ok {
+			log = log.With(WithEncoder(logfmt.Encoder{}))
+		}
+		log.Info("hello", String("b", "1"), Strings("a", []string{"x"}))
+		if w, ok := w.(AsyncLevelWriter); ok {
+			w.Close()
+		}
+		got, names = readJournaldEntry(t, conn)
+		if got["B"] != "1" || got["A"] != `["x"]` || got["MESSAGE"] != "hello" {
+			t.Errorf("entry = %q, want B, A and MESSAGE", got)
+		}
+		if got, want := strings.Join(names, ","), "PRIORITY,SYSLOG_IDENTIFIER,B,A,MESSAGE"; got != want {
+			t.Errorf("field names = %s, want %s", got, want)
+		}
+	}
+
+	callerLog := log.With(Fields(Caller(true)))
+	callerLog.Error("caller")
+	got, _ = readJournaldEntry(t, conn)
+	if got["PRIORITY"] != "3" || !strings.HasSuffix(got["CODE_FILE"], "writer_journald_test.go") || got["CODE_LINE"] == "" {
+		t.Errorf("entry = %q, want CODE_FILE and CODE_LINE", got)
+	}
+
+	large := strings.Repeat("a", 1<<20)
+	log.Info(large)
+	got, _ = readJournaldEntry(t, conn)
+	if got["MESSAGE"] != large {
+		t.Errorf("large entry MESSAGE length = %d, want %d", len(got["MESSAGE"]), len(large))
+	}
+}
@@ -0,0 +1,344 @@
+package rz
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// NetFraming defines how events are delimited on a stream connection (tcp, unix).
+// Each event is sent as a single datagram on datagram connections (udp, unixgram).
+type NetFraming int
+
+const (
+	// NetFramingNewline terminates each event with a newline, if it doesn't already end
+	// with one.
+	NetFramingNewline NetFraming = iota
+	// NetFramingLengthPrefix prefixes each event with its length as a 4 bytes big
+	// endian integer.
+	NetFramingLengthPrefix
+	// NetFramingOctetCounting prefixes each event with its length in decimal followed by
+	// a space, as defined by RFC 6587 for syslog over TCP.
+	NetFramingOctetCounting
+)
+
+const (
+	// DefaultNetBufferSize is the default number of events a network writer buffers
+	// while disconnected.
+	DefaultNetBufferSize = 1024
+	// DefaultNetMinBackoff is the default delay before the first reconnection attempt.
+	DefaultNetMinBackoff = 100 * time.Millisecond
+	// DefaultNetMaxBackoff is the default maximum delay between reconnection attempts.
+	DefaultNetMaxBackoff = 30 * time.Second
+	// DefaultNetDialTimeout is the default timeout of a connection attempt.
+	DefaultNetDialTimeout = 5 * time.Second
+	// DefaultNetWriteTimeout is the default timeout of the write of an event.
+	DefaultNetWriteTimeout = 5 * time.Second
+)
+
+// ErrNetEventDropped is reported when a network writer drops an event because its buffer
+// is full.
+var ErrNetEventDropped = errors.New("rz: network writer buffer full, event dropped")
+
+// NetWriterOption is used to configure a network writer.
+type NetWriterOption func(w *netWriter)
+
+// NetFramingMode update network writer's framing.
+func NetFramingMode(framing NetFraming) NetWriterOption {
+	return func(w *netWriter) {
+		w.framing = framing
+	}
+}
+
+// NetBufferSize update the number of events buffered while disconnected. When the buffer
+// is full, the oldest events are dropped.
+func NetBufferSize(size int) NetWriterOption {
+	return func(w *netWriter) {
+		w.bufferSize = size
+	}
+}
+
+// NetBackoff update the delays between reconnection attempts: the delay starts at min
+// and doubles after each failed attempt, up to max. A random jitter of up to half the
+// delay is applied. If min isn't positive, DefaultNetMinBackoff is used, and max is at
+// least min.
+func NetBackoff(min, max time.Duration) NetWriterOption {
+	return func(w *netWriter) {
+		if min <= 0 {
+			min = DefaultNetMinBackoff
+		}
+		if max < min {
+			max = min
+		}
+		w.minBackoff = min
+		w.maxBackoff = max
+	}
+}
+
+// NetDialTimeout update network writer's connection timeout.
+func NetDialTimeout(timeout time.Duration) NetWriterOption {
+	return func(w *netWriter) {
+		w.dialTimeout = timeout
+	}
+}
+
+// NetWriteTimeout update network writer's write timeout. A write timing out, e.g. because
+// the peer stopped reading, is handled as a disconnection: the event is buffered and the
+// writer reconnects. A timeout of 0 disables it.
+func NetWriteTimeout(timeout time.Duration) NetWriterOption {
+	return func(w *netWriter) {
+		w.writeTimeout = timeout
+	}
+}
+
+// NetTLSConfig makes the network writer use TLS on stream connections, with config.
+func NetTLSConfig(config *tls.Config) NetWriterOption {
+	return func(w *netWriter) {
+		w.tlsConfig = config
+	}
+}
+
+// NetErrorHandler update the function called with the dropped events and connection
+// errors. It defaults to rz.ErrorHandler. handler must not write to the network writer.
+func NetErrorHandler(handler func(err error)) NetWriterOption {
+	return func(w *netWriter) {
+		w.onError = handler
+	}
+}
+
+type netWriter struct {
+	network      string
+	address      string
+	framing      NetFraming
+	bufferSize   int
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	dialTimeout  time.Duration
+	writeTimeout time.Duration
+	tlsConfig    *tls.Config
+	onError      func(err error)
+	stream       bool
+
+	mu           sync.Mutex
+	conn         net.Conn
+	buffer       [][]byte // ring of buffered events
+	head         int      // index of the oldest buffered event
+	count        int      // number of buffered events
+	frame        []byte
+	reconnecting bool
+	closed       bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NetWriter creates a writer sending events to address on the named network: "tcp",
+// "tcp4", "tcp6", "udp", "udp4", "udp6", "unix" or "unixgram".
+// When the connection fails, the events are buffered and the writer reconnects in the
+// background with a jittered exponential backoff.
+func NetWriter(network, address string, options ...NetWriterOption) LevelWriteCloser {
+	w := &netWriter{
+		network:      network,
+		address:      address,
+		bufferSize:   DefaultNetBufferSize,
+		minBackoff:   DefaultNetMinBackoff,
+		maxBackoff:   DefaultNetMaxBackoff,
+		dialTimeout:  DefaultNetDialTimeout,
+		writeTimeout: DefaultNetWriteTimeout,
+		onError:      handleWriteError,
+		done:         make(chan struct{}),
+	}
+	for _, option := range options {
+		option(w)
+	}
+	switch network {
+	case "udp", "udp4", "udp6", "unixgram":
+	default:
+		w.stream = true
+	}
+
+	w.mu.Lock()
+	conn, err := w.dial()
+	if err != nil {
+		w.onError(err)
+		w.reconnect()
+	} else {
+		w.conn = conn
+	}
+	w.mu.Unlock()
+	return w
+}
+
+// Write implements the io.Writer interface.
+func (w *netWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, net.ErrClosed
+	}
+	if w.conn != nil {
+		if err = w.send(p); err == nil {
+			return len(p), nil
+		}
+		w.onError(err)
+		w.conn.Close()
+		w.conn = nil
+		w.reconnect()
+	}
+	w.bufferEvent(p)
+	return len(p), nil
+}
+
+// WriteLevel implements the LevelWriter interface.
+func (w *netWriter) WriteLevel(level LogLevel, p []byte) (n int, err error) {
+	return w.Write(p)
+}
+
+// Close stops reconnecting and closes the connection. The buffered events are dropped.
+func (w *netWriter) Close() (err error) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	if w.conn != nil {
+		err = w.conn.Close()
+		w.conn = nil
+	}
+	w.mu.Unlock()
+
+	close(w.done)
+	w.wg.Wait()
+	return err
+}
+
+func (w *netWriter) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: w.dialTimeout}
+	if w.tlsConfig != nil && w.stream {
+		return tls.DialWithDialer(dialer, w.network, w.address, w.tlsConfig)
+	}
+	return dialer.Dial(w.network, w.address)
+}
+
+// send writes the framed event to the connection. w.mu must be held.
+func (w *netWriter) send(p []byte) error {
+	frame := p
+	if w.stream {
+		switch w.framing {
+		case NetFramingLengthPrefix:
+			var size [4]byte
+			binary.BigEndian.PutUint32(size[:], uint32(len(p)))
+			frame = append(append(w.frame[:0], size[:]...), p...)
+			w.frame = frame
+		case NetFramingOctetCounting:
+			frame = append(append(strconv.AppendInt(w.frame[:0], int64(len(p)), 10), ' '), p...)
+			w.frame = frame
+		default:
+			if len(p) == 0 || p[len(p)-1] != '\n' {
+				frame = append(append(w.frame[:0], p...), '\n')
+				w.frame = frame
+			}
+		}
+	}
+	if w.writeTimeout > 0 {
+		if err := w.conn.SetWriteDeadline(time.Now().Add(w.writeTimeout)); err != nil {
+			return err
+		}
+	}
+	_, err := w.conn.Write(frame)
+	return err
+}
+
+// bufferEvent keeps a copy of p to be sent once reconnected. w.mu must be held.
+func (w *netWriter) bufferEvent(p []byte) {
+	if w.bufferSize <= 0 {
+		w.onError(ErrNetEventDropped)
+		return
+	}
+	if w.buffer == nil {
+		w.buffer = make([][]byte, w.bufferSize)
+	}
+	if w.count == len(w.buffer) {
+		// drop the oldest event, its memory is reused for p
+		w.head = (w.head + 1) % len(w.buffer)
+		w.count--
+		w.onError(ErrNetEventDropped)
+	}
+	i := (w.head + w.count) % len(w.buffer)
+	w.buffer[i] = append(w.buffer[i][:0], p...)
+	w.count++
+}
+
+// reconnect starts the reconnection goroutine, if not already running. w.mu must be held.
+func (w *netWriter) reconnect() {
+	if w.reconnecting || w.closed {
+		return
+	}
+	w.reconnecting = true
+	w.wg.Add(1)
+	go w.runReconnect()
+}
+
+func (w *netWriter) runReconnect() {
+	defer w.wg.Done()
+
+	backoff := w.minBackoff
+	for {
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-time.After(delay):
+		case <-w.done:
+			return
+		}
+		backoff *= 2
+		if backoff > w.maxBackoff {
+			backoff = w.maxBackoff
+		}
+
+		conn, err := w.dial()
+		if err != nil {
+			w.onError(err)
+			continue
+		}
+
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			conn.Close()
+			return
+		}
+		w.conn = conn
+		if err = w.flushBuffer(); err != nil {
+			w.onError(err)
+			conn.Close()
+			w.conn = nil
+			w.mu.Unlock()
+			continue
+		}
+		w.reconnecting = false
+		w.mu.Unlock()
+		return
+	}
+}
+
+// flushBuffer sends the buffered events. w.mu must be held.
+func (w *netWriter) flushBuffer() error {
+	for w.count > 0 {
+		if err := w.send(w.buffer[w.head]); err != nil {
+			return err
+		}
+		w.buffer[w.head] = nil
+		w.head = (w.head + 1) % len(w.buffer)
+		w.count--
+	}
+	w.buffer = nil
+	w.head = 0
+	return nil
+}
@@ -0,0 +1,214 @@
+package rz
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func readLines(t *testing.T, l net.Listener, n int) []string {
+	t.Helper()
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	lines := []string{}
+	for len(lines) < n {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestNetWriterTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	w := NetWriter("tcp", l.Addr().String())
+	defer w.Close()
+	log := New(Writer(w), Fields(Timestamp(false)))
+	log.Info("hello")
+	w.Write([]byte("no newline"))
+
+	got := readLines(t, l, 2)
+	want := []string{`{"level":"info","message":"hello"}` + "\n", "no newline\n"}
+	if got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestNetWriterReconnect(t *testing.T) {
+	// find a free port, and close the listener so the first connection fails
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	var mu sync.Mutex
+	var errs []error
+	w := NetWriter("tcp", address, NetBackoff(time.Millisecond, 10*time.Millisecond), NetBufferSize(2),
+		NetErrorHandler(func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}))
+	defer w.Close()
+	for _, p := range []string{"1\n", "2\n", "3\n"} {
+		w.Write([]byte(p))
+	}
+
+	l, err = net.Listen("tcp", address)
+	if err != nil {
+		t.Skipf("could not listen again on %s: %v", address, err)
+	}
+	defer l.Close()
+	got := readLines(t, l, 2)
+	if got[0] != "2\n" || got[1] != "3\n" {
+		t.Errorf("lines = %q, want the last 2 events", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	dropped := 0
+	for _, err := range errs {
+		if err == ErrNetEventDropped {
+			dropped++
+		}
+	}
+	if dropped != 1 {
+		t.Errorf("dropped = %d, want 1 (errors: %v)", dropped, errs)
+	}
+}
+
+func TestNetWriterWriteTimeout(t *testing.T) {
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "rz.sock"))
+	if err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+
+	var mu sync.Mutex
+	var timeouts int
+	w := NetWriter("unix", l.Addr().String(), NetWriteTimeout(10*time.Millisecond), NetBackoff(time.Hour, time.Hour),
+		NetErrorHandler(func(err error) {
+			if err, ok := err.(net.Error); ok && err.Timeout() {
+				mu.Lock()
+				timeouts++
+				mu.Unlock()
+			}
+		}))
+	defer w.Close()
+	// the peer doesn't read: writes block once the socket buffers are full
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	event := make([]byte, 64*1024)
+	for i := 0; i < 1000; i++ {
+		w.Write(event)
+		mu.Lock()
+		n := timeouts
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+	}
+	if timeouts != 1 {
+		t.Fatalf("timeouts = %d, want 1", timeouts)
+	}
+	nw := w.(*netWriter)
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	if nw.conn != nil || nw.count != 1 {
+		t.Errorf("connected = %v, buffered = %d, want the event buffered while disconnected", nw.conn != nil, nw.count)
+	}
+}
+
+func TestNetWriterLengthPrefix(t *testing.T) {
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "rz.sock"))
+	if err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+
+	w := NetWriter("unix", l.Addr().String(), NetFramingMode(NetFramingLengthPrefix))
+	defer w.Close()
+	w.Write([]byte("hello\n"))
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var size uint32
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, size)
+	if _, err := io.ReadFull(conn, p); err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "hello\n" {
+		t.Errorf("event = %q, want %q", p, "hello\n")
+	}
+}
+
+func TestNetWriterUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w := NetWriter("udp", conn.LocalAddr().String())
+	defer w.Close()
+	w.Write([]byte("hello"))
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	p := make([]byte, 100)
+	n, _, err := conn.ReadFrom(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p[:n]) != "hello" {
+		t.Errorf("datagram = %q, want %q", p[:n], "hello")
+	}
+}
+
+func TestNetBackoff(t *testing.T) {
+	tests := []struct {
+		min, max         time.Duration
+		wantMin, wantMax time.Duration
+	}{
+		{time.Millisecond, time.Second, time.Millisecond, time.Second},
+		{0, time.Second, DefaultNetMinBackoff, time.Second},
+		{-time.Second, 0, DefaultNetMinBackoff, DefaultNetMinBackoff},
+		{time.Second, time.Millisecond, time.Second, time.Second},
+	}
+	for _, tt := range tests {
+		w := &netWriter{}
+		NetBackoff(tt.min, tt.max)(w)
+		if w.minBackoff != tt.wantMin || w.maxBackoff != tt.wantMax {
+			t.Errorf("NetBackoff(%v, %v) = %v, %v, want %v, %v", tt.min, tt.max, w.minBackoff, w.maxBackoff, tt.wantMin, tt.wantMax)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package rz
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SyslogFacility is a syslog facility, as defined by RFC 5424.
+type SyslogFacility int
+
+// Syslog facilities.
+const (
+	SyslogKern SyslogFacility = iota
+	SyslogUser
+	SyslogMail
+	SyslogDaemon
+	SyslogAuth
+	SyslogSyslog
+	SyslogLpr
+	SyslogNews
+	SyslogUucp
+	SyslogCron
+	SyslogAuthpriv
+	SyslogFtp
+	_
+	_
+	_
+	_
+	SyslogLocal0
+	SyslogLocal1
+	SyslogLocal2
+	SyslogLocal3
+	SyslogLocal4
+	SyslogLocal5
+	SyslogLocal6
+	SyslogLocal7
+)
+
+// SyslogFormat is the format of the syslog messages.
+type SyslogFormat int
+
+const (
+	// SyslogRFC5424 formats messages as defined by RFC 5424.
+	SyslogRFC5424 SyslogFormat = iota
+	// SyslogRFC3164 formats messages in the legacy BSD format, as defined by RFC 3164.
+	SyslogRFC3164
+)
+
+const syslogNilValue = "-"
+
+// syslog severities, as defined by RFC 5424
+const (
+	syslogEmergency = iota
+	syslogAlert
+	syslogCritical
+	syslogError
+	syslogWarning
+	syslogNotice
+	syslogInformational
+	syslogDebug
+)
+
+// SyslogWriterOption is used to configure a syslog writer.
+type SyslogWriterOption func(w *syslogWriter)
+
+// WithSyslogFacility update syslog writer's facility. It defaults to SyslogUser.
+func WithSyslogFacility(facility SyslogFacility) SyslogWriterOption {
+	return func(w *syslogWriter) {
+		w.facility = facility
+	}
+}
+
+// WithSyslogFormat update syslog writer's format. It defaults to SyslogRFC5424.
+func WithSyslogFormat(format SyslogFormat) SyslogWriterOption {
+	return func(w *syslogWriter) {
+		w.format = format
+	}
+}
+
+// SyslogAppName update the app-name of the messages (the tag for RFC 3164). It defaults
+// to the name of the program.
+// Like the other header values, it's truncated to the length allowed by RFC 5424, and
+// its spaces and non printable characters are replaced by underscores.
+func SyslogAppName(appName string) SyslogWriterOption {
+	return func(w *syslogWriter) {
+		w.appName = appName
+	}
+}
+
+// SyslogMsgID update the msgid of the messages.
+func SyslogMsgID(msgID string) SyslogWriterOption {
+	return func(w *syslogWriter) {
+		w.msgID = msgID
+	}
+}
+
+// SyslogHostname update the hostname of the messages. It defaults to os.Hostname().
+func SyslogHostname(hostname string) SyslogWriterOption {
+	return func(w *syslogWriter) {
+		w.hostname = hostname
+	}
+}
+
+// SyslogStructuredData adds a structured data element id to the RFC 5424 messages, with
+// the given top-level fields of the events as parameters. The fields are read from the
+// events whatever their encoding, unless the syslog writer is wrapped by another writer,
+// e.g. an AsyncWriter: the events must then be encoded in JSON.
+// id and fields must be valid SD-NAMEs: up to 32 printable ASCII characters, except '=',
+// ' ', ']' and '"'. The invalid ones are ignored and reported to rz.ErrorHandler.
+func SyslogStructuredData(id string, fields ...string) SyslogWriterOption {
+	return func(w *syslogWriter) {
+		w.sdID = id
+		w.sdFields = fields
+	}
+}
+
+// SyslogTLSConfig makes the syslog writer use TLS over tcp, with config.
+func SyslogTLSConfig(config *tls.Config) SyslogWriterOption {
+	return func(w *syslogWriter) {
+		w.netOptions = append(w.netOptions, NetTLSConfig(config))
+	}
+}
+
+// SyslogNetOptions configures the underlying network writer.
+func SyslogNetOptions(options ...NetWriterOption) SyslogWriterOption {
+	return func(w *syslogWriter) {
+		w.netOptions = append(w.netOptions, options...)
+	}
+}
+
+type syslogWriter struct {
+	facility   SyslogFacility
+	format     SyslogFormat
+	appName    string
+	msgID      string
+	hostname   string
+	pid        string
+	sdID       string
+	sdFields   []string
+	netOptions []NetWriterOption
+	local      bool
+	now        func() time.Time
+
+	mu  sync.Mutex
+	buf []byte
+	w   LevelWriteCloser
+}
+
+// SyslogWriter creates a writer sending events to a syslog server. network is "udp",
+// "tcp" or "unix"/"unixgram"; if network is empty, the local syslog server is used
+// (/dev/log, /var/run/syslog or /var/run/log). Messages are framed with octet-counting
+// on tcp.
+// The level of the events is mapped to the syslog severity.
+func SyslogWriter(network, address string, options ...SyslogWriterOption) LevelWriteCloser {
+	w := &syslogWriter{
+		facility: SyslogUser,
+		appName:  filepath.Base(os.Args[0]),
+		pid:      strconv.Itoa(os.Getpid()),
+		now:      time.Now,
+	}
+	w.hostname, _ = os.Hostname()
+	for _, option := range options {
+		option(w)
+	}
+	w.hostname = syslogHeaderValue(w.hostname, 255)
+	w.appName = syslogHeaderValue(w.appName, 48)
+	w.pid = syslogHeaderValue(w.pid, 128)
+	w.msgID = syslogHeaderValue(w.msgID, 32)
+	w.validateStructuredData()
+
+	if network == "" {
+		w.local = true
+		network, address = localSyslog()
+	}
+	netOptions := w.netOptions
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		netOptions = append([]NetWriterOption{NetFramingMode(NetFramingOctetCounting)}, netOptions...)
+	case "unix", "unixgram":
+		w.local = true
+	}
+	w.w = NetWriter(network, address, netOptions...)
+	return w
+}
+
+// localSyslog finds the socket of the local syslog server.
+func localSyslog() (network, address string) {
+	for _, path := range []string{"/dev/log", "/var/run/syslog", "/var/run/log"} {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.Dial(network, path)
+			if err == nil {
+				conn.Close()
+				return network, path
+			}
+		}
+	}
+	return "unixgram", "/dev/log"
+}
+
+// Write implements the io.Writer interface.
+func (w *syslogWriter) Write(p []byte) (n int, err error) {
+	return w.WriteLevel(NoLevel, p)
+}
+
+// WriteLevel implements the LevelWriter interface.
+func (w *syslogWriter) WriteLevel(level LogLevel, p []byte) (n int, err error) {
+	return w.write(level, p, nil)
+}
+
+func (w *syslogWriter) writeEvent(e *Event) (n int, err error) {
+	return w.write(e.level, e.buf, e)
+}
+
+func (w *syslogWriter) usesEntry() bool {
+	return w.sdID != ""
+}
+
+// write sends p as a syslog message. The structured data is read from e if it's not nil.
+func (w *syslogWriter) write(level LogLevel, p []byte, e *Event) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = w.appendMessage(w.buf[:0], level, p, e)
+	if _, err = w.w.WriteLevel(level, w.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close implements the io.Closer interface.
+func (w *syslogWriter) Close() error {
+	return w.w.Close()
+}
+
+func (w *syslogWriter) appendMessage(dst []byte, level LogLevel, p []byte, e *Event) []byte {
+	priority := int(w.facility)*8 + syslogSeverity(level)
+	now := w.now()
+	dst = append(dst, '<')
+	dst = strconv.AppendInt(dst, int64(priority), 10)
+	dst = append(dst, '>')
+
+	if w.format == SyslogRFC3164 {
+		dst = now.AppendFormat(dst, time.Stamp)
+		dst = append(dst, ' ')
+		// local syslog servers add the hostname themselves
+		if !w.local {
+			dst = append(dst, w.hostname...)
+			dst = append(dst, ' ')
+		}
+		dst = append(dst, w.appName...)
+		dst = append(dst, '[')
+		dst = append(dst, w.pid...)
+		dst = append(dst, "]: "...)
+	} else {
+		dst = append(dst, "1 "...)
+		dst = now.AppendFormat(dst, "2006-01-02T15:04:05.000000Z07:00")
+		for _, value := range []string{w.hostname, w.appName, w.pid, w.msgID} {
+			dst = append(dst, ' ')
+			dst = append(dst, value...)
+		}
+		dst = append(dst, ' ')
+		dst = w.appendStructuredData(dst, p, e)
+		dst = append(dst, ' ')
+	}
+
+	return append(dst, bytes.TrimRight(p, "\n")...)
+}
+
+// validateStructuredData disables the structured data if its id is invalid, and removes
+// the invalid parameter names.
+func (w *syslogWriter) validateStructuredData() {
+	if w.sdID == "" {
+		return
+	}
+	if !validSyslogSDName(w.sdID) {
+		handleWriteError(fmt.Errorf("rz: invalid syslog structured data id %q", w.sdID))
+		w.sdID = ""
+		w.sdFields = nil
+		return
+	}
+	fields := make([]string, 0, len(w.sdFields))
+	for _, field := range w.sdFields {
+		if validSyslogSDName(field) {
+			fields = append(fields, field)
+		} else {
+			handleWriteError(fmt.Errorf("rz: invalid syslog structured data parameter name %q", field))
+		}
+	}
+	w.sdFields = fields
+}
+
+// validSyslogSDName returns true if name is a valid SD-NAME, as defined by RFC 5424.
+func validSyslogSDName(name string) bool {
+	if len(name) == 0 || len(name) > 32 {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *syslogWriter) appendStructuredData(dst []byte, p []byte, e *Event) []byte {
+	if w.sdID == "" {
+		return append(dst, syslogNilValue...)
+	}
+	lookup := func(field string) (string, bool) {
+		value, ok := e.Lookup(field)
+		if !ok {
+			return "", false
+		}
+		return entryValueText(value, e.timeFieldFormat), true
+	}
+	if e == nil || !e.withEntry {
+		// the event is only known by its bytes
+		var event map[string]interface{}
+		d := json.NewDecoder(bytes.NewReader(p))
+		d.UseNumber()
+		if err := d.Decode(&event); err != nil {
+			return append(dst, syslogNilValue...)
+		}
+		lookup = func(field string) (string, bool) {
+			value, ok := event[field]
+			if !ok {
+				return "", false
+			}
+			return entryValueText(value, ""), true
+		}
+	}
+
+	dst = append(dst, '[')
+	dst = append(dst, w.sdID...)
+	for _, field := range w.sdFields {
+		s, ok := lookup(field)
+		if !ok {
+			continue
+		}
+		dst = append(dst, ' ')
+		dst = append(dst, field...)
+		dst = append(dst, `="`...)
+		for i := 0; i < len(s); i++ {
+			switch s[i] {
+			case '"', '\\', ']':
+				dst = append(dst, '\\')
+			}
+			dst = append(dst, s[i])
+		}
+		dst = append(dst, '"')
+	}
+	return append(dst, ']')
+}
+
+// syslogHeaderValue returns value truncated to maxLen, with the characters not allowed in
+// RFC 5424 header fields replaced by underscores, or the NILVALUE if it's empty.
+func syslogHeaderValue(value string, maxLen int) string {
+	if value == "" {
+		return syslogNilValue
+	}
+	b := []byte(value)
+	if len(b) > maxLen {
+		b = b[:maxLen]
+	}
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// syslogSeverity maps level to a syslog severity. Custom levels use the severity of the
+// predefined level below them, or notice between InfoLevel and WarnLevel.
+func syslogSeverity(level LogLevel) int {
+	switch {
+	case level == NoLevel || level == Disabled:
+		return syslogInformational
+	case level.Severity() < InfoLevel.Severity():
+		return syslogDebug
+	case level.Severity() == InfoLevel.Severity():
+		return syslogInformational
+	case level.Severity() < WarnLevel.Severity():
+		return syslogNotice
+	case level.Severity() < ErrorLevel.Severity():
+		return syslogWarning
+	case level.Severity() < FatalLevel.Severity():
+		return syslogError
+	case level.Severity() < PanicLevel.Severity():
+		return syslogCritical
+	default:
+		return syslogAlert
+	}
+}
@@ -0,0 +1,156 @@
+package rz
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skerkour/rz/logfmt"
+)
+
+var syslogTestTime = time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+func TestSyslogWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	tests := []struct {
+		name    string
+		options []SyslogWriterOption
+		log     func(log Logger)
+		want    string
+	}{
+		{
+			"RFC5424",
+			[]SyslogWriterOption{WithSyslogFacility(SyslogLocal0), SyslogMsgID("ID47")},
+			func(log Logger) { log.Warn("hello") },
+			`<132>1 2020-01-02T03:04:05.000006Z host app 42 ID47 - {"level":"warning","message":"hello"}`,
+		},
+		{
+			"RFC5424StructuredData",
+			[]SyslogWriterOption{SyslogStructuredData("req@32473", "id", "path", "missing")},
+			func(log Logger) { log.Error("hello", Int("id", 1), String("path", `/a"]`)) },
+			`<11>1 2020-01-02T03:04:05.000006Z host app 42 - [req@32473 id="1" path="/a\"\]"] {"level":"error","id":1,"path":"/a\"]","message":"hello"}`,
+		},
+		{
+			"StructuredDataLogfmt",
+			[]SyslogWriterOption{SyslogStructuredData("req@32473", "id", "user")},
+			func(log Logger) {
+				log = log.With(WithEncoder(logfmt.Encoder{}))
+				log.Info("hello", Int("id", 1), Dict("user", log.NewDict(String("name", "bob"))))
+			},
+			`<14>1 2020-01-02T03:04:05.000006Z host app 42 - [req@32473 id="1" user="{\"name\":\"bob\"}"] level=info id=1 user.name=bob message=hello`,
+		},
+		{
+			"RFC3164",
+			[]SyslogWriterOption{WithSyslogFormat(SyslogRFC3164), WithSyslogFacility(SyslogDaemon)},
+			func(log Logger) { log.Debug("hello") },
+			`<31>Jan  2 03:04:05 app[42]: {"level":"debug","message":"hello"}`,
+		},
+		{
+			"NoLevel",
+			nil,
+			func(log Logger) { log.Log("hello") },
+			`<14>1 2020-01-02T03:04:05.000006Z host app 42 - - {"message":"hello"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]SyslogWriterOption{SyslogAppName("app"), SyslogHostname("host")}, tt.options...)
+			w := SyslogWriter("unixgram", path, options...)
+			defer w.Close()
+			sw := w.(*syslogWriter)
+			sw.pid = "42"
+			sw.now = func() time.Time { return syslogTestTime }
+
+			tt.log(New(Writer(w), Fields(Timestamp(false))))
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			p := make([]byte, 1024)
+			n, _, err := conn.ReadFrom(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(p[:n]); got != tt.want {
+				t.Errorf("message:\ngot:  %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyslogWriterTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	w := SyslogWriter("tcp", l.Addr().String(), SyslogAppName("app"), SyslogHostname("host"))
+	defer w.Close()
+	sw := w.(*syslogWriter)
+	sw.pid = "42"
+	sw.now = func() time.Time { return syslogTestTime }
+	w.WriteLevel(InfoLevel, []byte("hello\n"))
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	msg := `<14>1 2020-01-02T03:04:05.000006Z host app 42 - - hello`
+	want := strconv.Itoa(len(msg)) + " " + msg
+	p := make([]byte, len(want))
+	if _, err := io.ReadFull(conn, p); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(p); got != want {
+		t.Errorf("frame:\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestSyslogWriterValidation(t *testing.T) {
+	var errs []string
+	ErrorHandler = func(err error) {
+		// ignore the connection errors
+		if strings.Contains(err.Error(), "invalid syslog") {
+			errs = append(errs, err.Error())
+		}
+	}
+	defer func() { ErrorHandler = nil }()
+
+	w := SyslogWriter("unixgram", filepath.Join(t.TempDir(), "log"), SyslogHostname("my host"),
+		SyslogAppName("app\n"+strings.Repeat("a", 60)), SyslogMsgID(""),
+		SyslogStructuredData("req", "id", "bad name", `a"b`, "c=d", "ok]"))
+	defer w.Close()
+	sw := w.(*syslogWriter)
+	if sw.hostname != "my_host" {
+		t.Errorf("hostname = %q, want %q", sw.hostname, "my_host")
+	}
+	if want := "app_" + strings.Repeat("a", 44); sw.appName != want {
+		t.Errorf("appName = %q, want %q", sw.appName, want)
+	}
+	if sw.msgID != syslogNilValue {
+		t.Errorf("msgID = %q, want %q", sw.msgID, syslogNilValue)
+	}
+	if got := strings.Join(sw.sdFields, ","); got != "id" {
+		t.Errorf("structured data fields = %q, want %q", got, "id")
+	}
+	if len(errs) != 4 {
+		t.Errorf("errors = %v, want 4 invalid parameter names", errs)
+	}
+
+	errs = nil
+	sw = SyslogWriter("unixgram", filepath.Join(t.TempDir(), "log"), SyslogStructuredData("bad id", "id")).(*syslogWriter)
+	defer sw.Close()
+	if sw.sdID != "" || len(errs) != 1 {
+		t.Errorf("structured data id = %q, errors = %v, want it disabled and reported", sw.sdID, errs)
+	}
+}
@@ -2,6 +2,67 @@
 
 package rz
 
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiodeWriter(t *testing.T) {
+	for _, options := range [][]DiodeWriterOption{nil, {DiodePollInterval(time.Millisecond)}} {
+		out := &bytes.Buffer{}
+		missed := 0
+		w := DiodeWriter(out, 1000, append(options, DiodeAlert(func(n int) { missed += n }))...)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					w.Write([]byte(strconv.Itoa(i*100+j) + "\n"))
+				}
+			}(i)
+		}
+		wg.Wait()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if err := w.Flush(ctx); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if got := strings.Count(out.String(), "\n") + missed; got != 1000 {
+			t.Errorf("written + missed events = %d, want 1000", got)
+		}
+		w.Close()
+		if _, err := w.Write([]byte("closed\n")); err != ErrAsyncWriterClosed {
+			t.Errorf("Write() after Close() error = %v, want %v", err, ErrAsyncWriterClosed)
+		}
+	}
+}
+
+func TestDiodeWriterMissed(t *testing.T) {
+	bw := newBlockingWriter()
+	missed := 0
+	w := DiodeWriter(bw, 2, DiodeAlert(func(n int) { missed += n }))
+	w.Write([]byte("0"))
+	// wait for "0" to be written so the ring is empty
+	<-bw.started
+	for _, p := range []string{"1", "2", "3", "4"} {
+		w.Write([]byte(p))
+	}
+	close(bw.release)
+	w.Close()
+	if got, want := strings.Join(bw.events, ","), "0,3,4"; got != want {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if missed != 2 {
+		t.Errorf("missed = %d, want 2", missed)
+	}
+}
+
 // func TestMultiSyslogWriter(t *testing.T) {
 // 	sw := &syslogTestWriter{}
 // 	log := New(MultiLevelWriter(SyslogLevelWriter(sw)))